@@ -52,23 +52,39 @@ type credentials struct {
 
 // CredentialsClient is the interface that wraps Get method
 // Get gets credentials from the endpoint using provided id
+//
+// Implementations are backends of the CA issuing credentials: EnrollClient
+// for the controller CA, VaultClient for Vault PKI and ESTClient for EST
+// servers.
 type CredentialsClient interface {
 	Get(id *pb.Identity, timeout time.Duration,
 		endpoint string) (*pb.Credentials, error)
 }
 
 // EnrollClient implements CredentialsClient interface
-type EnrollClient struct{}
+type EnrollClient struct {
+	// Pinning of the controller identity, system roots are trusted if nil
+	Pinning *ControllerPinning
+}
 
 // Get gets credentials from gRPC endpoint using TLS connection
 func (c EnrollClient) Get(id *pb.Identity, timeout time.Duration,
 	endpoint string) (*pb.Credentials, error) {
 
-	pool, err := x509.SystemCertPool()
-	if err != nil {
-		return nil, errors.Wrap(err, "Failed to get system cert pool")
+	var creds grpcCreds.TransportCredentials
+	if c.Pinning != nil {
+		tlsCfg, err := c.Pinning.TLSConfig(EnrollServerName)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to create TLS config")
+		}
+		creds = grpcCreds.NewTLS(tlsCfg)
+	} else {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to get system cert pool")
+		}
+		creds = grpcCreds.NewClientTLSFromCert(pool, EnrollServerName)
 	}
-	creds := grpcCreds.NewClientTLSFromCert(pool, EnrollServerName)
 
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
@@ -0,0 +1,213 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package auth
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	pb "github.com/open-ness/edgenode/pkg/auth/pb"
+	"github.com/pkg/errors"
+)
+
+// maxESTResponseSize limits the size of responses of EST servers
+const maxESTResponseSize = 1024 * 1024
+
+// ESTClient implements CredentialsClient with an EST (RFC 7030) server,
+// endpoint is the base URL of the server, e.g. https://est.example.com
+type ESTClient struct {
+	// Label of the CA on servers hosting several ones, optional
+	Label string
+	// Username and Password for HTTP basic authentication, optional
+	Username string
+	Password string
+	// TLSConfig of connections to the server, system roots are trusted if
+	// nil. Certificates of the config are used for TLS client
+	// authentication.
+	TLSConfig *tls.Config
+}
+
+// Get gets the CA certificates and enrolls the CSR of the identity
+func (c ESTClient) Get(id *pb.Identity, timeout time.Duration,
+	endpoint string) (*pb.Credentials, error) {
+
+	block, _ := pem.Decode([]byte(id.GetCsr()))
+	if block == nil {
+		return nil, errors.New("Failed to decode CSR")
+	}
+
+	client := &http.Client{
+		Transport: &http.Transport{TLSClientConfig: c.TLSConfig},
+		Timeout:   timeout,
+	}
+	caCerts, err := c.request(client, endpoint, "cacerts", nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get CA certificates")
+	}
+	certs, err := c.request(client, endpoint, "simpleenroll", block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to enroll")
+	}
+
+	chain, err := buildChain(certs[0], caCerts)
+	if err != nil {
+		return nil, err
+	}
+	creds := &pb.Credentials{Certificate: encodeCert(certs[0])}
+	for _, cert := range chain {
+		creds.CaChain = append(creds.CaChain, encodeCert(cert))
+	}
+	creds.CaPool = creds.CaChain[len(creds.CaChain)-1:]
+	return creds, nil
+}
+
+// request sends a request to the EST operation and returns certificates
+// of the response, csr is sent if not nil
+func (c ESTClient) request(client *http.Client, endpoint, operation string,
+	csr []byte) ([]*x509.Certificate, error) {
+
+	url := strings.TrimSuffix(endpoint, "/") + "/.well-known/est/"
+	if c.Label != "" {
+		url += c.Label + "/"
+	}
+	url += operation
+
+	method := http.MethodGet
+	var body io.Reader
+	if csr != nil {
+		method = http.MethodPost
+		body = strings.NewReader(base64.StdEncoding.EncodeToString(csr))
+	}
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create request")
+	}
+	if csr != nil {
+		req.Header.Set("Content-Type", "application/pkcs10")
+		req.Header.Set("Content-Transfer-Encoding", "base64")
+	}
+	if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to send request to %s", url)
+	}
+	defer func() {
+		if err1 := resp.Body.Close(); err1 != nil {
+			log.Errf("Failed to close response body: %v", err1)
+		}
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("%s responded %s", url, resp.Status)
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxESTResponseSize))
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to read response")
+	}
+	der, err := base64.StdEncoding.DecodeString(
+		strings.Map(func(r rune) rune {
+			if r == '\r' || r == '\n' || r == ' ' {
+				return -1
+			}
+			return r
+		}, string(data)))
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to decode response")
+	}
+	return parseCertsOnlyPKCS7(der)
+}
+
+// pkcs7ContentInfo is the ContentInfo of a PKCS#7 (RFC 2315) message
+type pkcs7ContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,tag:0"`
+}
+
+// pkcs7SignedData is the SignedData of a PKCS#7 message, EST uses
+// certs-only messages with no signers
+type pkcs7SignedData struct {
+	Version          int
+	DigestAlgorithms asn1.RawValue
+	ContentInfo      asn1.RawValue
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      asn1.RawValue
+}
+
+var oidPKCS7SignedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+
+// parseCertsOnlyPKCS7 returns certificates of a DER encoded certs-only
+// PKCS#7 message
+func parseCertsOnlyPKCS7(der []byte) ([]*x509.Certificate, error) {
+	var info pkcs7ContentInfo
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, errors.Wrap(err, "Failed to parse PKCS#7 message")
+	}
+	if !info.ContentType.Equal(oidPKCS7SignedData) {
+		return nil, errors.Errorf("Unexpected PKCS#7 content type %v",
+			info.ContentType)
+	}
+
+	var signedData pkcs7SignedData
+	if _, err := asn1.Unmarshal(info.Content.Bytes, &signedData); err != nil {
+		return nil, errors.Wrap(err, "Failed to parse PKCS#7 signed data")
+	}
+	certs, err := x509.ParseCertificates(signedData.Certificates.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to parse certificates")
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("PKCS#7 message has no certificates")
+	}
+	return certs, nil
+}
+
+// buildChain orders CA certificates from the issuer of the certificate
+// towards the root, certificates not in the chain are left out
+func buildChain(cert *x509.Certificate,
+	caCerts []*x509.Certificate) ([]*x509.Certificate, error) {
+
+	var chain []*x509.Certificate
+	for current := cert; ; {
+		var issuer *x509.Certificate
+		for _, ca := range caCerts {
+			if !ca.Equal(current) &&
+				bytes.Equal(current.RawIssuer, ca.RawSubject) &&
+				current.CheckSignatureFrom(ca) == nil {
+				issuer = ca
+				break
+			}
+		}
+		if issuer == nil || len(chain) == len(caCerts) {
+			break
+		}
+		chain = append(chain, issuer)
+		current = issuer
+	}
+
+	if len(chain) == 0 {
+		return nil, errors.New("Issuer of the certificate is not a CA")
+	}
+	return chain, nil
+}
+
+// encodeCert PEM encodes the certificate
+func encodeCert(cert *x509.Certificate) string {
+	return string(pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: cert.Raw,
+	}))
+}
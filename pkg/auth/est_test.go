@@ -0,0 +1,147 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package auth_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/open-ness/edgenode/pkg/auth"
+	pb "github.com/open-ness/edgenode/pkg/auth/pb"
+)
+
+// certsOnlyPKCS7 encodes certificates as a base64 certs-only PKCS#7
+// message
+func certsOnlyPKCS7(certs ...*x509.Certificate) []byte {
+	var raw []byte
+	for _, cert := range certs {
+		raw = append(raw, cert.Raw...)
+	}
+	signedData, err := asn1.Marshal(struct {
+		Version          int
+		DigestAlgorithms []asn1.RawValue `asn1:"set"`
+		ContentInfo      struct{ ContentType asn1.ObjectIdentifier }
+		Certificates     asn1.RawValue
+		SignerInfos      []asn1.RawValue `asn1:"set"`
+	}{
+		Version: 1,
+		ContentInfo: struct{ ContentType asn1.ObjectIdentifier }{
+			asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}},
+		Certificates: asn1.RawValue{Class: asn1.ClassContextSpecific,
+			Tag: 0, IsCompound: true, Bytes: raw},
+	})
+	Expect(err).ToNot(HaveOccurred())
+	der, err := asn1.Marshal(struct {
+		ContentType asn1.ObjectIdentifier
+		Content     asn1.RawValue
+	}{
+		ContentType: asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2},
+		Content: asn1.RawValue{Class: asn1.ClassContextSpecific,
+			Tag: 0, IsCompound: true, Bytes: signedData},
+	})
+	Expect(err).ToNot(HaveOccurred())
+	return []byte(base64.StdEncoding.EncodeToString(der))
+}
+
+var _ = Describe("EST", func() {
+	var (
+		dir    string
+		server *httptest.Server
+		caKey  *ecdsa.PrivateKey
+		caCert *x509.Certificate
+		other  *x509.Certificate
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir(os.TempDir(), "est")
+		Expect(err).ToNot(HaveOccurred())
+
+		caKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).ToNot(HaveOccurred())
+		caCert, err = genCert(caKey, true)
+		Expect(err).ToNot(HaveOccurred())
+		otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).ToNot(HaveOccurred())
+		other, err = genCert(otherKey, true)
+		Expect(err).ToNot(HaveOccurred())
+
+		server = httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				defer GinkgoRecover()
+				user, pass, ok := r.BasicAuth()
+				if !ok || user != "node" || pass != "secret" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+
+				switch r.URL.Path {
+				case "/.well-known/est/cacerts":
+					_, _ = w.Write(certsOnlyPKCS7(other, caCert))
+				case "/.well-known/est/simpleenroll":
+					Expect(r.Header.Get("Content-Type")).To(
+						Equal("application/pkcs10"))
+					body, err := ioutil.ReadAll(r.Body)
+					Expect(err).ToNot(HaveOccurred())
+					csrDER, err := base64.StdEncoding.DecodeString(string(body))
+					Expect(err).ToNot(HaveOccurred())
+					csr, err := x509.ParseCertificateRequest(csrDER)
+					Expect(err).ToNot(HaveOccurred())
+
+					der, err := x509.CreateCertificate(rand.Reader,
+						&x509.Certificate{
+							SerialNumber: big.NewInt(2),
+							NotBefore:    time.Now(),
+							NotAfter:     caCert.NotAfter,
+						}, caCert, csr.PublicKey, caKey)
+					Expect(err).ToNot(HaveOccurred())
+					cert, err := x509.ParseCertificate(der)
+					Expect(err).ToNot(HaveOccurred())
+					_, _ = w.Write(certsOnlyPKCS7(cert))
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.RemoveAll(dir)
+	})
+
+	It("Enrolls with the EST server", func() {
+		certsDir := filepath.Join(dir, "certs")
+		Expect(auth.Enroll(certsDir, server.URL, time.Second,
+			auth.ESTClient{Username: "node", Password: "secret"})).To(
+			Succeed())
+
+		chain, err := auth.LoadCerts(filepath.Join(certsDir,
+			auth.CAChainName))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(chain).To(HaveLen(1))
+		Expect(chain[0].Equal(caCert)).To(BeTrue())
+	})
+
+	It("Fails when the EST server rejects the client", func() {
+		_, err := auth.ESTClient{Username: "node"}.Get(&pb.Identity{
+			Csr: "-----BEGIN CERTIFICATE REQUEST-----\n" +
+				"-----END CERTIFICATE REQUEST-----\n",
+		}, time.Second, server.URL)
+		Expect(err).To(HaveOccurred())
+	})
+})
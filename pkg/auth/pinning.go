@@ -0,0 +1,108 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+
+	"github.com/pkg/errors"
+)
+
+// ControllerPinning describes verification of the controller identity
+// stricter than the system roots and hostname checks
+type ControllerPinning struct {
+	// CACerts replace the system roots when verifying the controller
+	// certificate if not empty
+	CACerts []*x509.Certificate
+	// SPKIHashes are base64 encoded SHA-256 hashes of the
+	// SubjectPublicKeyInfo of certificates, one of the certificates of the
+	// verified chain must match one of them if not empty
+	SPKIHashes []string
+}
+
+// SPKIHash returns the base64 encoded SHA-256 hash of the
+// SubjectPublicKeyInfo of the certificate
+func SPKIHash(cert *x509.Certificate) string {
+	hash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(hash[:])
+}
+
+// TLSConfig returns TLS config of connections to the controller with
+// serverName. Apart from the pins, the controller certificate must list
+// serverName as one of its DNS SANs, wildcard names don't match.
+func (p *ControllerPinning) TLSConfig(serverName string) (*tls.Config,
+	error) {
+
+	var (
+		roots *x509.CertPool
+		err   error
+	)
+	if len(p.CACerts) > 0 {
+		roots = x509.NewCertPool()
+		for _, cert := range p.CACerts {
+			roots.AddCert(cert)
+		}
+	} else if roots, err = x509.SystemCertPool(); err != nil {
+		return nil, errors.Wrap(err, "Failed to get system cert pool")
+	}
+
+	var pins [][]byte
+	for _, pin := range p.SPKIHashes {
+		hash, err := base64.StdEncoding.DecodeString(pin)
+		if err != nil || len(hash) != sha256.Size {
+			return nil, errors.Errorf("Invalid SPKI hash %q", pin)
+		}
+		pins = append(pins, hash)
+	}
+
+	return &tls.Config{
+		RootCAs:    roots,
+		ServerName: serverName,
+		VerifyPeerCertificate: func(_ [][]byte,
+			verifiedChains [][]*x509.Certificate) error {
+			return verifyController(serverName, pins, verifiedChains)
+		},
+	}, nil
+}
+
+// verifyController checks the SAN of the controller certificate and the
+// pins against chains already verified by the TLS handshake
+func verifyController(serverName string, pins [][]byte,
+	verifiedChains [][]*x509.Certificate) error {
+
+	if len(verifiedChains) == 0 || len(verifiedChains[0]) == 0 {
+		return errors.New("Controller certificate is not verified")
+	}
+
+	hasName := false
+	for _, name := range verifiedChains[0][0].DNSNames {
+		if name == serverName {
+			hasName = true
+			break
+		}
+	}
+	if !hasName {
+		return errors.Errorf("Controller certificate is not issued for %s",
+			serverName)
+	}
+
+	if len(pins) == 0 {
+		return nil
+	}
+	for _, chain := range verifiedChains {
+		for _, cert := range chain {
+			hash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+			for _, pin := range pins {
+				if subtle.ConstantTimeCompare(hash[:], pin) == 1 {
+					return nil
+				}
+			}
+		}
+	}
+	return errors.New("Controller certificate chain doesn't match any pin")
+}
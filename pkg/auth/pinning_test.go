@@ -0,0 +1,88 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package auth_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/open-ness/edgenode/pkg/auth"
+	pb "github.com/open-ness/edgenode/pkg/auth/pb"
+)
+
+var _ = Describe("Controller pinning", func() {
+	const endpoint = "localhost:61919"
+
+	var (
+		id     *pb.Identity
+		caCert *x509.Certificate
+	)
+
+	get := func(pinning auth.ControllerPinning) error {
+		_, err := auth.EnrollClient{Pinning: &pinning}.Get(id,
+			500*time.Millisecond, endpoint)
+		return err
+	}
+
+	BeforeEach(func() {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).ToNot(HaveOccurred())
+		csr, err := x509.CreateCertificateRequest(rand.Reader,
+			&x509.CertificateRequest{}, key)
+		Expect(err).ToNot(HaveOccurred())
+		id = &pb.Identity{Csr: string(pem.EncodeToMemory(
+			&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csr}))}
+
+		block, _ := pem.Decode(CACert)
+		Expect(block).ToNot(BeNil())
+		caCert, err = x509.ParseCertificate(block.Bytes)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("Accepts the controller issued by the pinned CA", func() {
+		Expect(get(auth.ControllerPinning{
+			CACerts: []*x509.Certificate{caCert},
+		})).To(Succeed())
+	})
+
+	It("Rejects the controller issued by another CA", func() {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).ToNot(HaveOccurred())
+		other, err := genCert(key, true)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(get(auth.ControllerPinning{
+			CACerts: []*x509.Certificate{other},
+		})).ToNot(Succeed())
+	})
+
+	It("Checks SPKI pins", func() {
+		Expect(get(auth.ControllerPinning{
+			CACerts:    []*x509.Certificate{caCert},
+			SPKIHashes: []string{auth.SPKIHash(caCert)},
+		})).To(Succeed())
+
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).ToNot(HaveOccurred())
+		other, err := genCert(key, true)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(get(auth.ControllerPinning{
+			CACerts:    []*x509.Certificate{caCert},
+			SPKIHashes: []string{auth.SPKIHash(other)},
+		})).ToNot(Succeed())
+	})
+
+	It("Rejects invalid SPKI pins", func() {
+		pinning := auth.ControllerPinning{SPKIHashes: []string{"invalid"}}
+		_, err := pinning.TLSConfig(auth.EnrollServerName)
+		Expect(err).To(HaveOccurred())
+	})
+})
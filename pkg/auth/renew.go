@@ -0,0 +1,186 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const defaultRenewRetryInterval = time.Minute
+
+// Renewed credentials are saved to a new version directory in CertsDir,
+// files in CertsDir are links through the data link which is switched to
+// the version directory at once
+const (
+	credsVersionPrefix = "..creds_"
+	credsDataLinkName  = "..data"
+)
+
+// RenewConfig describes renewal of credentials saved by Enroll
+type RenewConfig struct {
+	CertsDir string
+	Endpoint string
+	Timeout  time.Duration
+	// LeadTime is how long before expiry the certificate is renewed, it's
+	// clamped to half of the validity of certificates valid for less
+	LeadTime time.Duration
+	// RetryInterval is the time between failed renewal attempts, a minute
+	// by default
+	RetryInterval time.Duration
+	Client        CredentialsClient
+	// Alert is called with the error of every failed renewal attempt
+	Alert func(error)
+}
+
+// Renew renews credentials in CertsDir LeadTime ahead of expiry of the
+// certificate until ctx is done. Every renewal generates a new key, the new
+// credentials replace the current ones at once after they are verified.
+// The first renewal turns files saved by Enroll into links one by one, so
+// only then readers of the files may briefly see a mismatched key and
+// certificate.
+func Renew(ctx context.Context, cfg RenewConfig) {
+	retry := cfg.RetryInterval
+	if retry == 0 {
+		retry = defaultRenewRetryInterval
+	}
+
+	for {
+		wait := retry
+		if renewAt, err := renewCredentials(cfg); err != nil {
+			log.Errf("Failed to renew credentials: %v", err)
+			if cfg.Alert != nil {
+				cfg.Alert(err)
+			}
+		} else if untilRenewal := time.Until(renewAt); untilRenewal > 0 {
+			wait = untilRenewal
+			log.Infof("Credentials will be renewed at %v", renewAt)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// renewCredentials renews credentials in CertsDir if the certificate
+// expires within LeadTime and returns the time of the next renewal
+func renewCredentials(cfg RenewConfig) (time.Time, error) {
+	c, err := loadCredentials(cfg.CertsDir)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "Failed to load credentials")
+	}
+	renewAt := renewalTime(c.cert, cfg.LeadTime)
+	if time.Now().Before(renewAt) {
+		return renewAt, nil
+	}
+
+	log.Infof("Renewing credentials expiring at %v from %s",
+		c.cert.NotAfter, cfg.Endpoint)
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "Failed to generate key")
+	}
+	c, err = requestCredentials(key, &x509.CertificateRequest{},
+		cfg.Endpoint, cfg.Timeout, cfg.Client)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if err = c.replace(cfg.CertsDir); err != nil {
+		return time.Time{}, err
+	}
+
+	log.Infof("Credentials renewed, new certificate expires at %v",
+		c.cert.NotAfter)
+	return renewalTime(c.cert, cfg.LeadTime), nil
+}
+
+// renewalTime returns the time of renewal of the certificate leadTime
+// ahead of its expiry. A lead time not shorter than the validity of the
+// certificate would make it due for renewal as soon as it's issued, so it's
+// clamped to half of the validity.
+func renewalTime(cert *x509.Certificate, leadTime time.Duration) time.Time {
+	if validity := cert.NotAfter.Sub(cert.NotBefore); leadTime >= validity {
+		log.Warningf("Renewal lead time %v exceeds certificate validity %v,"+
+			" using %v", leadTime, validity, validity/2)
+		leadTime = validity / 2
+	}
+	return cert.NotAfter.Add(-leadTime)
+}
+
+// replace saves credentials to a new version directory in certsDir and
+// switches the data link to it, files in certsDir link to the same files
+// through the data link
+func (c *credentials) replace(certsDir string) error {
+	versionDir, err := ioutil.TempDir(certsDir, credsVersionPrefix)
+	if err != nil {
+		return errors.Wrap(err, "Failed to create credentials directory")
+	}
+	if err = c.save(versionDir); err != nil {
+		if err1 := os.RemoveAll(versionDir); err1 != nil {
+			log.Errf("Failed to remove %s: %v", versionDir, err1)
+		}
+		return errors.Wrap(err, "Failed to save renewed credentials")
+	}
+
+	if err = replaceWithLink(filepath.Base(versionDir),
+		filepath.Join(certsDir, credsDataLinkName)); err != nil {
+		return err
+	}
+	for _, name := range []string{CAPoolName, CAChainName, CertName,
+		KeyName} {
+		target := filepath.Join(credsDataLinkName, name)
+		path := filepath.Join(certsDir, name)
+		if current, err := os.Readlink(path); err == nil && current == target {
+			continue
+		}
+		if err = replaceWithLink(target, path); err != nil {
+			return err
+		}
+	}
+
+	// Previous versions and those left by interrupted renewals
+	versions, err := filepath.Glob(filepath.Join(certsDir,
+		credsVersionPrefix+"*"))
+	if err != nil {
+		return errors.Wrap(err, "Failed to list credentials directories")
+	}
+	for _, dir := range versions {
+		if dir == versionDir {
+			continue
+		}
+		if err = os.RemoveAll(dir); err != nil {
+			log.Errf("Failed to remove %s: %v", dir, err)
+		}
+	}
+	return nil
+}
+
+// replaceWithLink atomically replaces path with a symbolic link to target
+func replaceWithLink(target, path string) error {
+	tmpPath := path + ".tmp"
+	if err := os.Remove(tmpPath); err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "Failed to remove %s", tmpPath)
+	}
+	if err := os.Symlink(target, tmpPath); err != nil {
+		return errors.Wrapf(err, "Failed to create link %s", tmpPath)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return errors.Wrapf(err, "Failed to replace %s", path)
+	}
+	return nil
+}
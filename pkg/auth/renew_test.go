@@ -0,0 +1,146 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package auth_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/open-ness/edgenode/pkg/auth"
+	pb "github.com/open-ness/edgenode/pkg/auth/pb"
+)
+
+var _ = Describe("Renewal", func() {
+	// Stub certificates are valid for an hour, renewal is due a couple of
+	// seconds after they're issued
+	const (
+		dueLeadTime    = time.Hour - 2*time.Second
+		renewalTimeout = 5 * time.Second
+	)
+
+	var (
+		dir    string
+		ctx    context.Context
+		cancel context.CancelFunc
+		done   chan struct{}
+	)
+
+	fingerprint := func() string {
+		cert, err := auth.LoadCert(filepath.Join(dir, auth.CertName))
+		Expect(err).ToNot(HaveOccurred())
+		key, err := ioutil.ReadFile(filepath.Join(dir, auth.KeyName))
+		Expect(err).ToNot(HaveOccurred())
+		return cert.NotBefore.String() + string(key)
+	}
+
+	renew := func(cfg auth.RenewConfig) {
+		cfg.CertsDir = dir
+		cfg.Timeout = time.Second
+		cfg.RetryInterval = 10 * time.Millisecond
+		go func() {
+			auth.Renew(ctx, cfg)
+			close(done)
+		}()
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir(os.TempDir(), "renew")
+		Expect(err).ToNot(HaveOccurred())
+		dir = filepath.Join(dir, "certs")
+		Expect(auth.Enroll(dir, "", time.Second,
+			enrollClientStub{getCredSuccess})).To(Succeed())
+
+		ctx, cancel = context.WithCancel(context.Background())
+		done = make(chan struct{})
+	})
+
+	AfterEach(func() {
+		cancel()
+		Eventually(done).Should(BeClosed())
+		os.RemoveAll(filepath.Dir(dir))
+	})
+
+	It("Renews credentials ahead of expiry", func() {
+		before := fingerprint()
+		renewed := make(chan struct{}, 10)
+		renew(auth.RenewConfig{
+			LeadTime: dueLeadTime,
+			Client: enrollClientStub{getHandler: func(id *pb.Identity,
+				timeout time.Duration, endpoint string) (*pb.Credentials, error) {
+				defer func() { renewed <- struct{}{} }()
+				return getCredSuccess(id, timeout, endpoint)
+			}},
+		})
+
+		Eventually(renewed, renewalTimeout).Should(Receive())
+		Eventually(fingerprint).ShouldNot(Equal(before))
+		Expect(auth.Enroll(dir, "", time.Second,
+			enrollClientStub{getCredFail})).To(Succeed())
+
+		// Files link to the only version directory through a single link
+		// switched at once
+		renewedOnce := fingerprint()
+		Eventually(renewed, renewalTimeout).Should(Receive())
+		Eventually(fingerprint).ShouldNot(Equal(renewedOnce))
+		for _, name := range []string{auth.CAPoolName, auth.CAChainName,
+			auth.CertName, auth.KeyName} {
+			target, err := os.Readlink(filepath.Join(dir, name))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(target).To(Equal(filepath.Join("..data", name)))
+		}
+		versions, err := filepath.Glob(filepath.Join(dir, "..creds_*"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(versions).To(HaveLen(1))
+		target, err := os.Readlink(filepath.Join(dir, "..data"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(filepath.Join(dir, target)).To(Equal(versions[0]))
+	})
+
+	It("Keeps credentials valid for longer than lead time", func() {
+		before := fingerprint()
+		alerts := make(chan error, 10)
+		renew(auth.RenewConfig{
+			LeadTime: time.Minute,
+			Client:   enrollClientStub{getCredFail},
+			Alert:    func(err error) { alerts <- err },
+		})
+
+		Consistently(alerts, 100*time.Millisecond).ShouldNot(Receive())
+		Expect(fingerprint()).To(Equal(before))
+	})
+
+	It("Clamps lead time to the certificate validity", func() {
+		before := fingerprint()
+		alerts := make(chan error, 10)
+		renew(auth.RenewConfig{
+			LeadTime: 2 * time.Hour,
+			Client:   enrollClientStub{getCredFail},
+			Alert:    func(err error) { alerts <- err },
+		})
+
+		Consistently(alerts, 100*time.Millisecond).ShouldNot(Receive())
+		Expect(fingerprint()).To(Equal(before))
+	})
+
+	It("Alerts on renewal failure", func() {
+		before := fingerprint()
+		alerts := make(chan error, 10)
+		renew(auth.RenewConfig{
+			LeadTime: dueLeadTime,
+			Client:   enrollClientStub{getCredFail},
+			Alert:    func(err error) { alerts <- err },
+		})
+
+		Eventually(alerts, renewalTimeout).Should(Receive())
+		Eventually(alerts).Should(Receive())
+		Expect(fingerprint()).To(Equal(before))
+	})
+})
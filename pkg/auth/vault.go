@@ -0,0 +1,119 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package auth
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	pb "github.com/open-ness/edgenode/pkg/auth/pb"
+	"github.com/pkg/errors"
+)
+
+const defaultVaultMount = "pki"
+
+// VaultClient implements CredentialsClient with the PKI secrets engine of
+// HashiCorp Vault, endpoint is the address of Vault, e.g.
+// https://vault:8200
+type VaultClient struct {
+	// Mount is the path of the PKI secrets engine, "pki" by default
+	Mount string
+	// Role is the name of the role signing certificates
+	Role string
+	// Token authenticates requests to Vault
+	Token string
+	// CommonName of the certificate, required by roles that don't take it
+	// from the CSR
+	CommonName string
+	// TLSConfig of connections to Vault, system roots are trusted if nil
+	TLSConfig *tls.Config
+}
+
+// vaultSignRequest is the body of the Vault PKI sign request
+type vaultSignRequest struct {
+	CSR        string `json:"csr"`
+	CommonName string `json:"common_name,omitempty"`
+	Format     string `json:"format"`
+}
+
+// vaultSignResponse is the body of the Vault PKI sign response
+type vaultSignResponse struct {
+	Data struct {
+		Certificate string   `json:"certificate"`
+		IssuingCA   string   `json:"issuing_ca"`
+		CAChain     []string `json:"ca_chain"`
+	} `json:"data"`
+	Errors []string `json:"errors"`
+}
+
+// Get signs the CSR of the identity with the Vault role
+func (c VaultClient) Get(id *pb.Identity, timeout time.Duration,
+	endpoint string) (*pb.Credentials, error) {
+
+	mount := c.Mount
+	if mount == "" {
+		mount = defaultVaultMount
+	}
+	body, err := json.Marshal(vaultSignRequest{
+		CSR:        id.GetCsr(),
+		CommonName: c.CommonName,
+		Format:     "pem",
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to encode sign request")
+	}
+
+	url := strings.TrimSuffix(endpoint, "/") + "/v1/" + mount + "/sign/" +
+		c.Role
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create sign request")
+	}
+	req.Header.Set("X-Vault-Token", c.Token)
+	req.Header.Set("Content-Type", "application/json")
+
+	client := http.Client{
+		Transport: &http.Transport{TLSClientConfig: c.TLSConfig},
+		Timeout:   timeout,
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to send sign request to %s",
+			endpoint)
+	}
+	defer func() {
+		if err1 := resp.Body.Close(); err1 != nil {
+			log.Errf("Failed to close response body: %v", err1)
+		}
+	}()
+
+	var signResp vaultSignResponse
+	if resp.StatusCode != http.StatusOK {
+		// Errors are reported in the body unless a proxy in front of Vault
+		// responded
+		if err = json.NewDecoder(resp.Body).Decode(&signResp); err != nil {
+			return nil, errors.Errorf("Vault failed to sign certificate (%s)",
+				resp.Status)
+		}
+		return nil, errors.Errorf("Vault failed to sign certificate (%s): %s",
+			resp.Status, strings.Join(signResp.Errors, "; "))
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&signResp); err != nil {
+		return nil, errors.Wrap(err, "Failed to decode sign response")
+	}
+
+	chain := signResp.Data.CAChain
+	if len(chain) == 0 {
+		chain = []string{signResp.Data.IssuingCA}
+	}
+	return &pb.Credentials{
+		Certificate: signResp.Data.Certificate,
+		CaChain:     chain,
+		CaPool:      chain[len(chain)-1:],
+	}, nil
+}
@@ -0,0 +1,104 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package auth_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/open-ness/edgenode/pkg/auth"
+	pb "github.com/open-ness/edgenode/pkg/auth/pb"
+)
+
+var _ = Describe("Vault PKI", func() {
+	var (
+		dir    string
+		server *httptest.Server
+		client auth.VaultClient
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir(os.TempDir(), "vault")
+		Expect(err).ToNot(HaveOccurred())
+		client = auth.VaultClient{
+			Role:       "node",
+			Token:      "s.token",
+			CommonName: "node.openness",
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.RemoveAll(dir)
+	})
+
+	It("Enrolls with the Vault role", func() {
+		server = httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				defer GinkgoRecover()
+				Expect(r.Method).To(Equal(http.MethodPost))
+				Expect(r.URL.Path).To(Equal("/v1/pki/sign/node"))
+				Expect(r.Header.Get("X-Vault-Token")).To(Equal("s.token"))
+
+				var req struct {
+					CSR        string `json:"csr"`
+					CommonName string `json:"common_name"`
+				}
+				Expect(json.NewDecoder(r.Body).Decode(&req)).To(Succeed())
+				Expect(req.CommonName).To(Equal("node.openness"))
+
+				creds, err := credSuccess(&pb.Identity{Csr: req.CSR},
+					true, true, true, true)
+				Expect(err).ToNot(HaveOccurred())
+				resp := map[string]interface{}{
+					"data": map[string]interface{}{
+						"certificate": creds.Certificate,
+						"issuing_ca":  creds.CaChain[0],
+						"ca_chain":    creds.CaChain[:1],
+					},
+				}
+				Expect(json.NewEncoder(w).Encode(resp)).To(Succeed())
+			}))
+
+		certsDir := filepath.Join(dir, "certs")
+		Expect(auth.Enroll(certsDir, server.URL, time.Second,
+			client)).To(Succeed())
+		_, err := auth.LoadCert(filepath.Join(certsDir, auth.CertName))
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("Fails when Vault denies the request", func() {
+		server = httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusForbidden)
+				_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+			}))
+
+		_, err := client.Get(&pb.Identity{}, time.Second, server.URL)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("permission denied"))
+	})
+
+	It("Reports the status when the response isn't from Vault", func() {
+		server = httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadGateway)
+				_, _ = w.Write([]byte("<html>Bad Gateway</html>"))
+			}))
+
+		_, err := client.Get(&pb.Identity{}, time.Second, server.URL)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("502 Bad Gateway"))
+		Expect(err.Error()).ToNot(ContainSubstring("decode"))
+	})
+})
@@ -40,6 +40,22 @@ type config struct {
 	CaKeyPath      string
 }
 
+// Validate checks the configuration and returns an error listing all
+// problems found
+func (c *config) Validate() error {
+	var v configutil.Validator
+
+	v.Required("SignerName", c.SignerName)
+	v.Required("ControllerName", c.ControllerName)
+	v.Check(c.ResyncPeriod.Duration >= 0,
+		"ResyncPeriod: must not be negative")
+	v.Check(c.CertTTL.Duration > 0, "CertTTL: must be positive")
+	v.File("CaCertPath", c.CaCertPath)
+	v.File("CaKeyPath", c.CaKeyPath)
+
+	return v.Err()
+}
+
 // CertificateSigner is a wrapper for Kubernetes Certificate Controller
 type CertificateSigner struct {
 	clientset kubernetes.Interface
@@ -57,12 +73,16 @@ func NewCertificateSigner(clientset kubernetes.Interface) *CertificateSigner {
 // Run creates and starts the Kubernetes Certificate Controller
 func (c *CertificateSigner) Run(ctx context.Context, cfgPath string) error {
 	logger.Infof("Loading config from path: \"%v\"", cfgPath)
-	err := configutil.LoadJSONConfig(cfgPath, &c.cfg)
+	err := configutil.LoadConfig(cfgPath, "CERTSIGNER", &c.cfg)
 	if err != nil {
 		return errors.Wrapf(err, "Failed to load config from path: \"%v\"", cfgPath)
 	}
 	logger.Debugf("Loaded config: %#v", c.cfg)
 
+	if err = c.cfg.Validate(); err != nil {
+		return errors.Wrapf(err, "Failed to validate config from path: \"%v\"", cfgPath)
+	}
+
 	ca, err := loadCA(c.cfg.CaCertPath, c.cfg.CaKeyPath)
 	if err != nil {
 		return errors.Wrap(err, "Failed to load CA")
@@ -6,7 +6,14 @@ package config
 import (
 	"encoding/json"
 	"io/ioutil"
+	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
 )
 
 // LoadJSONConfig reads a file located at configPath and unmarshals it to
@@ -18,3 +25,123 @@ func LoadJSONConfig(configPath string, config interface{}) error {
 	}
 	return json.Unmarshal(cfgData, config)
 }
+
+// LoadYAMLConfig reads a YAML file located at configPath and unmarshals it
+// to config structure. Field names are taken from the json tags, so the
+// same structure serves both formats.
+func LoadYAMLConfig(configPath string, config interface{}) error {
+	cfgData, err := ioutil.ReadFile(filepath.Clean(configPath))
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(cfgData, config)
+}
+
+// LoadConfig reads a JSON or YAML (.yaml or .yml extension) file located at
+// configPath, unmarshals it to config structure and then applies overrides
+// from environment variables prefixed with envPrefix (see ApplyEnvOverrides)
+func LoadConfig(configPath, envPrefix string, config interface{}) error {
+	var err error
+	switch strings.ToLower(filepath.Ext(configPath)) {
+	case ".yaml", ".yml":
+		err = LoadYAMLConfig(configPath, config)
+	default:
+		err = LoadJSONConfig(configPath, config)
+	}
+	if err != nil {
+		return err
+	}
+	return ApplyEnvOverrides(envPrefix, config)
+}
+
+// ApplyEnvOverrides overrides fields of the config structure with values of
+// environment variables. Variable name is the envPrefix followed by the
+// field's json name converted to upper snake case, nested structures add
+// their own name, e.g. EAA_CERTS_CA_ROOT_PATH for Certs.CaRootPath of EAA.
+// String fields take the value as is, other fields take it as JSON
+// (numbers, booleans, objects) or as a JSON string (e.g. durations).
+func ApplyEnvOverrides(envPrefix string, config interface{}) error {
+	v := reflect.ValueOf(config)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return errors.New("config must be a pointer to a structure")
+	}
+	return applyEnvOverrides(strings.ToUpper(envPrefix), v.Elem())
+}
+
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+func applyEnvOverrides(prefix string, v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name := field.Name
+		if tag := strings.Split(field.Tag.Get("json"), ",")[0]; tag != "" {
+			if tag == "-" {
+				continue
+			}
+			name = tag
+		}
+		envName := toEnvName(name)
+		if prefix != "" {
+			envName = prefix + "_" + envName
+		}
+
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Struct &&
+			!fv.Addr().Type().Implements(jsonUnmarshalerType) {
+			if err := applyEnvOverrides(envName, fv); err != nil {
+				return err
+			}
+			continue
+		}
+
+		val, ok := os.LookupEnv(envName)
+		if !ok {
+			continue
+		}
+		if err := setFromEnv(fv, val); err != nil {
+			return errors.Wrapf(err, "Failed to apply %s", envName)
+		}
+	}
+	return nil
+}
+
+func setFromEnv(fv reflect.Value, val string) error {
+	if fv.Kind() == reflect.String {
+		fv.SetString(val)
+		return nil
+	}
+
+	ptr := reflect.New(fv.Type())
+	if err := json.Unmarshal([]byte(val), ptr.Interface()); err != nil {
+		quoted, _ := json.Marshal(val)
+		if json.Unmarshal(quoted, ptr.Interface()) != nil {
+			return err
+		}
+	}
+	fv.Set(ptr.Elem())
+	return nil
+}
+
+// toEnvName converts CamelCase name to UPPER_SNAKE_CASE keeping acronyms
+// together, e.g. KafkaCAPath becomes KAFKA_CA_PATH
+func toEnvName(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) ||
+				(unicode.IsUpper(prev) && nextLower) {
+				b.WriteRune('_')
+			}
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
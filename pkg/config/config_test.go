@@ -4,10 +4,14 @@
 package config
 
 import (
+	"os"
 	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+
+	"github.com/open-ness/edgenode/pkg/util"
 )
 
 func TestConfig(t *testing.T) {
@@ -30,3 +34,117 @@ var _ = Describe("LoadJSONonfig", func() {
 			})
 	})
 })
+
+type nestedConf struct {
+	KafkaCAPath string `json:"KafkaCAPath"`
+}
+
+type testConf struct {
+	Name     string            `json:"Name"`
+	Val      int               `json:"Val"`
+	Enabled  bool              `json:"Enabled"`
+	Interval util.Duration     `json:"HeartbeatInterval"`
+	Services map[string]string `json:"Services"`
+	Nested   nestedConf        `json:"Nested"`
+	Ignored  string            `json:"-"`
+}
+
+var _ = Describe("LoadConfig", func() {
+	envs := map[string]string{}
+
+	setEnv := func(name, val string) {
+		envs[name] = val
+		Expect(os.Setenv(name, val)).To(Succeed())
+	}
+
+	AfterEach(func() {
+		for name := range envs {
+			Expect(os.Unsetenv(name)).To(Succeed())
+		}
+		envs = map[string]string{}
+	})
+
+	Describe("Load a yaml config file", func() {
+		It("Will unmarshall it to structure using json field names",
+			func() {
+				var conf testConf
+				Expect(LoadConfig("testdata/conf.yaml", "TEST", &conf)).To(Succeed())
+				Expect(conf.Name).To(Equal("yaml"))
+				Expect(conf.Val).To(Equal(5))
+				Expect(conf.Nested.KafkaCAPath).To(Equal("ca.pem"))
+			})
+	})
+
+	Describe("Load a config file with environment overrides", func() {
+		It("Will override fields from environment variables",
+			func() {
+				setEnv("TEST_NAME", "env")
+				setEnv("TEST_VAL", "7")
+				setEnv("TEST_ENABLED", "true")
+				setEnv("TEST_HEARTBEAT_INTERVAL", "30s")
+				setEnv("TEST_SERVICES", `{"svc":"svc.json"}`)
+				setEnv("TEST_NESTED_KAFKA_CA_PATH", "env-ca.pem")
+
+				var conf testConf
+				Expect(LoadConfig("testdata/nested.json", "TEST", &conf)).To(Succeed())
+				Expect(conf.Name).To(Equal("env"))
+				Expect(conf.Val).To(Equal(7))
+				Expect(conf.Enabled).To(BeTrue())
+				Expect(conf.Interval.Duration).To(Equal(30 * time.Second))
+				Expect(conf.Services).To(Equal(map[string]string{"svc": "svc.json"}))
+				Expect(conf.Nested.KafkaCAPath).To(Equal("env-ca.pem"))
+			})
+
+		It("Will fail for a value not matching the field type",
+			func() {
+				setEnv("TEST_VAL", "seven")
+
+				var conf testConf
+				err := LoadConfig("testdata/nested.json", "TEST", &conf)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("TEST_VAL"))
+			})
+
+		It("Will fail for a config that is not a pointer to structure",
+			func() {
+				var conf map[string]interface{}
+				Expect(LoadConfig("testdata/nested.json", "TEST", &conf)).
+					NotTo(Succeed())
+			})
+	})
+})
+
+var _ = Describe("Validator", func() {
+	It("Will succeed for valid values", func() {
+		var v Validator
+		v.Required("Name", "value")
+		v.Endpoint("Endpoint", ":443")
+		v.Endpoint("Endpoint", "localhost:8080")
+		v.ListenEndpoint("Endpoint", "localhost:0")
+		v.File("File", "testdata/conf.json")
+		v.Check(true, "never reported")
+		Expect(v.Err()).To(BeNil())
+	})
+
+	It("Will report all problems at once", func() {
+		var v Validator
+		v.Required("Name", "")
+		v.Endpoint("Endpoint", "localhost")
+		v.Endpoint("Port", "localhost:99999")
+		v.Endpoint("Ephemeral", "localhost:0")
+		v.File("File", "testdata/nonexistent-file")
+		v.File("Dir", "testdata")
+		v.File("Empty", "")
+		v.Check(false, "Interval: %s", "must be positive")
+
+		err := v.Err()
+		Expect(err).To(HaveOccurred())
+		for _, p := range []string{"Name: value is required",
+			"Endpoint: invalid address", "Port: invalid port",
+			"Ephemeral: invalid port",
+			"File:", "Dir: testdata is not a regular file",
+			"Empty: file path is required", "Interval: must be positive"} {
+			Expect(err.Error()).To(ContainSubstring(p))
+		}
+	})
+})
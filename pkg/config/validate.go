@@ -0,0 +1,82 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package config
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Validator collects configuration problems so that all of them are
+// reported at once instead of failing on the first one
+type Validator struct {
+	problems []string
+}
+
+// Check records a problem described by format and args if ok is false
+func (v *Validator) Check(ok bool, format string, args ...interface{}) {
+	if !ok {
+		v.problems = append(v.problems, fmt.Sprintf(format, args...))
+	}
+}
+
+// Required checks that the value of the field is not empty
+func (v *Validator) Required(field, value string) {
+	v.Check(value != "", "%s: value is required", field)
+}
+
+// Endpoint checks that the value of the field is a valid host:port address,
+// host may be empty to listen on all interfaces
+func (v *Validator) Endpoint(field, addr string) {
+	v.endpoint(field, addr, 1)
+}
+
+// ListenEndpoint checks that the value of the field is a valid host:port
+// address to listen on, port may be 0 to listen on an ephemeral port
+func (v *Validator) ListenEndpoint(field, addr string) {
+	v.endpoint(field, addr, 0)
+}
+
+func (v *Validator) endpoint(field, addr string, minPort int) {
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		v.Check(false, "%s: invalid address %q, expected host:port",
+			field, addr)
+		return
+	}
+	p, err := strconv.Atoi(port)
+	v.Check(err == nil && p >= minPort && p <= 65535,
+		"%s: invalid port in address %q", field, addr)
+}
+
+// File checks that the field points to an existing regular file
+func (v *Validator) File(field, path string) {
+	if path == "" {
+		v.Check(false, "%s: file path is required", field)
+		return
+	}
+	fi, err := os.Stat(filepath.Clean(path))
+	if err != nil {
+		v.Check(false, "%s: %v", field, err)
+		return
+	}
+	v.Check(fi.Mode().IsRegular(), "%s: %s is not a regular file",
+		field, path)
+}
+
+// Err returns an error listing all recorded problems or nil if there
+// are none
+func (v *Validator) Err() error {
+	if len(v.problems) == 0 {
+		return nil
+	}
+	return errors.Errorf("invalid configuration:\n  - %s",
+		strings.Join(v.problems, "\n  - "))
+}
@@ -0,0 +1,48 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package eaa
+
+import "path"
+
+// Actions of applications controlled by access rules
+const (
+	// accessActionRegister allows to register a service
+	accessActionRegister = "register"
+	// accessActionDiscover allows to see a service in the list of services
+	accessActionDiscover = "discover"
+	// accessActionSubscribe allows to subscribe to notifications of
+	// a service, subscription to a whole namespace is checked with
+	// "<namespace>:*" target
+	accessActionSubscribe = "subscribe"
+)
+
+// allowed checks if the client application is allowed to perform the action
+// on the target service URN
+func (c *AccessControlConfig) allowed(client, action, target string) bool {
+	if !c.Enabled {
+		return true
+	}
+
+	for _, rule := range c.Rules {
+		if !matchPattern(rule.Client, client) {
+			continue
+		}
+		if rule.Target != "" && !matchPattern(rule.Target, target) {
+			continue
+		}
+		for _, a := range rule.Actions {
+			if a == action {
+				return true
+			}
+		}
+	}
+
+	log.Infof("Access denied: %s can't %s %s", client, action, target)
+	return false
+}
+
+func matchPattern(pattern, name string) bool {
+	matched, err := path.Match(pattern, name)
+	return err == nil && matched
+}
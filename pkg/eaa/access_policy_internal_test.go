@@ -0,0 +1,63 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package eaa
+
+import (
+	g "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = g.Describe("AccessControlConfig", func() {
+	acl := AccessControlConfig{
+		Enabled: true,
+		Rules: []AccessRule{
+			{Client: "vendor-a:*", Actions: []string{accessActionRegister}},
+			{Client: "vendor-a:*", Actions: []string{accessActionDiscover,
+				accessActionSubscribe}, Target: "vendor-a:*"},
+			{Client: "monitor:app", Actions: []string{accessActionSubscribe},
+				Target: "vendor-?:producer"},
+		},
+	}
+
+	g.It("allows everything when disabled", func() {
+		disabled := AccessControlConfig{Rules: acl.Rules}
+		Expect(disabled.allowed("vendor-b:app", accessActionRegister,
+			"vendor-b:app")).To(BeTrue())
+	})
+
+	g.It("allows actions matching a rule", func() {
+		Expect(acl.allowed("vendor-a:app", accessActionRegister,
+			"vendor-a:app")).To(BeTrue())
+		Expect(acl.allowed("vendor-a:app", accessActionDiscover,
+			"vendor-a:producer")).To(BeTrue())
+		Expect(acl.allowed("vendor-a:app", accessActionSubscribe,
+			"vendor-a:*")).To(BeTrue())
+		Expect(acl.allowed("monitor:app", accessActionSubscribe,
+			"vendor-b:producer")).To(BeTrue())
+	})
+
+	g.It("denies actions not matching any rule", func() {
+		Expect(acl.allowed("vendor-b:app", accessActionRegister,
+			"vendor-b:app")).To(BeFalse())
+		Expect(acl.allowed("vendor-a:app", accessActionDiscover,
+			"vendor-b:producer")).To(BeFalse())
+		Expect(acl.allowed("monitor:app", accessActionSubscribe,
+			"vendor-b:*")).To(BeFalse())
+		Expect(acl.allowed("monitor:app", accessActionDiscover,
+			"vendor-b:producer")).To(BeFalse())
+	})
+
+	g.It("rejects invalid rules", func() {
+		cfg := Config{AccessControl: AccessControlConfig{Rules: []AccessRule{
+			{Client: "[", Actions: []string{accessActionRegister}},
+			{Client: "ns:*", Actions: []string{"publish"}},
+		}}}
+		err := cfg.Validate()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring(
+			`AccessControl.Rules[0].Client: invalid pattern "["`))
+		Expect(err.Error()).To(ContainSubstring(
+			`AccessControl.Rules[1].Actions: unknown action "publish"`))
+	})
+})
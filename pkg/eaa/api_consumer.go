@@ -6,8 +6,12 @@ package eaa
 import (
 	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gorilla/websocket"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // Set read and write buffer sizes for websocket connection, these should be
@@ -18,19 +22,36 @@ var socket = websocket.Upgrader{
 }
 
 // createWsConn creates a websocket connection for a consumer
-// to receive data from subscribed producers
-func createWsConn(w http.ResponseWriter, r *http.Request) (int, error) {
+// to receive data from subscribed producers. On failure it returns the HTTP
+// status code and the error code of the response, unless the connection
+// upgrade has already responded.
+func createWsConn(w http.ResponseWriter, r *http.Request) (int, string, error) {
 	eaaCtx := r.Context().Value(contextKey("appliance-ctx")).(*Context)
 
-	// Get the consumer app ID from the Common Name in the certificate
-	commonName := r.TLS.PeerCertificates[0].Subject.CommonName
+	// Get the consumer app ID from the Common Name in the certificate or
+	// the identity from the bearer token
+	commonName := clientCommonName(r)
 
 	// Check if urn ID matches the Host included in the request header
 	if commonName != r.Host {
-		return http.StatusUnauthorized,
+		return http.StatusUnauthorized, ErrorCodeUnauthorized,
 			errors.New("401: Incorrect app ID")
 	}
 
+	since, replay, err := parseReplayCursor(r)
+	if err != nil {
+		return http.StatusBadRequest, ErrorCodeInvalidRequest, err
+	}
+
+	var acks *pendingDeliveries
+	if r.URL.Query().Get("ack") == "true" {
+		if eaaCtx.cfg.Delivery.MaxRetries == 0 {
+			return http.StatusBadRequest, ErrorCodeAcksDisabled,
+				errors.New("400: Acknowledgments are not enabled")
+		}
+		acks = newPendingDeliveries()
+	}
+
 	eaaCtx.consumerConnections.Lock()
 	defer eaaCtx.consumerConnections.Unlock()
 
@@ -39,19 +60,7 @@ func createWsConn(w http.ResponseWriter, r *http.Request) (int, error) {
 	// connections structure
 	foundConn, connFound := eaaCtx.consumerConnections.m[commonName]
 	if connFound {
-		prevConn := foundConn.connection
-		msgType := websocket.CloseMessage
-		closeMessage := websocket.FormatCloseMessage(
-			websocket.CloseServiceRestart,
-			"New connection request, closing this connection")
-		err := prevConn.WriteMessage(msgType, closeMessage)
-		if err != nil {
-			log.Info("Failed to send close message to old connection")
-		}
-		err = prevConn.Close()
-		if err != nil {
-			log.Info("Failed to close previous websocket connection")
-		}
+		closeConsumerConnection(foundConn)
 		delete(eaaCtx.consumerConnections.m, commonName)
 	}
 
@@ -62,13 +71,173 @@ func createWsConn(w http.ResponseWriter, r *http.Request) (int, error) {
 	conn, err := socket.Upgrade(w, r, nil)
 	if err != nil {
 		delete(eaaCtx.consumerConnections.m, commonName)
-		return 0, err
+		return 0, "", err
+	}
+
+	// Take the notifications missed by the consumer together with
+	// registering the connection, so that each notification is either
+	// replayed or queued. They are sent ahead of the queue by watchWsConn,
+	// not while holding the connections lock.
+	var replayed [][]byte
+	if replay {
+		replayed = eaaCtx.notificationBuffer.since(commonName, since)
 	}
 
+	var queue chan queuedNotification
+	if eaaCtx.cfg.NotificationLimits.SendQueueSize > 0 || replay {
+		queue = make(chan queuedNotification, sendQueueSize(eaaCtx))
+	}
 	eaaCtx.consumerConnections.m[commonName] = ConsumerConnection{
-		connection: conn}
+		connection: conn, acks: acks, queue: queue}
+
+	keepalive := eaaCtx.cfg.Keepalive
+	if keepalive.PingInterval.Duration > 0 ||
+		keepalive.IdleTimeout.Duration > 0 || acks != nil || queue != nil {
+		go watchWsConn(conn, commonName, replayed, eaaCtx)
+	}
+
+	return 0, "", nil
+}
+
+// controlWriteTimeout limits the time of sending a websocket control message
+const controlWriteTimeout = 5 * time.Second
+
+// notificationWriteTimeout limits the time of sending a notification over
+// a websocket connection
+const notificationWriteTimeout = 5 * time.Second
+
+// watchWsConn keeps the websocket connection of a consumer alive as
+// configured by Keepalive, sends replayed and queued notifications, handles
+// acknowledgments of notifications and removes the connection when it's closed or the consumer is idle for too
+// long
+func watchWsConn(conn *websocket.Conn, commonName string, replayed [][]byte,
+	eaaCtx *Context) {
+	pingInterval := eaaCtx.cfg.Keepalive.PingInterval.Duration
+	idleTimeout := eaaCtx.cfg.Keepalive.IdleTimeout.Duration
+
+	eaaCtx.consumerConnections.RLock()
+	acks := eaaCtx.consumerConnections.m[commonName].acks
+	queue := eaaCtx.consumerConnections.m[commonName].queue
+	eaaCtx.consumerConnections.RUnlock()
+
+	extendDeadline := func() error {
+		if idleTimeout <= 0 {
+			return nil
+		}
+		return conn.SetReadDeadline(time.Now().Add(idleTimeout))
+	}
+
+	if err := extendDeadline(); err != nil {
+		log.Errf("Failed to set websocket deadline of %s: %v", commonName, err)
+	}
+	conn.SetPongHandler(func(string) error {
+		return extendDeadline()
+	})
+	conn.SetPingHandler(func(data string) error {
+		if err := extendDeadline(); err != nil {
+			return err
+		}
+		return conn.WriteControl(websocket.PongMessage, []byte(data),
+			time.Now().Add(controlWriteTimeout))
+	})
+
+	done := make(chan struct{})
+	if pingInterval > 0 {
+		go func() {
+			ticker := time.NewTicker(pingInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-done:
+					return
+				case <-ticker.C:
+					err := conn.WriteControl(websocket.PingMessage, nil,
+						time.Now().Add(controlWriteTimeout))
+					if err != nil {
+						log.Debugf("Failed to ping %s: %v", commonName, err)
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	if acks != nil {
+		go acks.retryUntilDone(conn, commonName, done, eaaCtx)
+	}
+	if queue != nil {
+		go sendQueuedNotifications(conn, replayed, queue, acks, commonName,
+			done, eaaCtx)
+	}
 
-	return 0, nil
+	// Control messages are processed only while reading, anything else sent
+	// by the consumer but acknowledgments just counts as activity
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			log.Infof("Notifications connection of %s closed: %v",
+				commonName, err)
+			break
+		}
+		if acks != nil {
+			acks.ack(data, commonName, eaaCtx)
+		}
+		if err := extendDeadline(); err != nil {
+			break
+		}
+	}
+	close(done)
+
+	eaaCtx.consumerConnections.Lock()
+	if c, found := eaaCtx.consumerConnections.m[commonName]; found &&
+		c.connection == conn {
+		delete(eaaCtx.consumerConnections.m, commonName)
+	}
+	eaaCtx.consumerConnections.Unlock()
+
+	if err := conn.Close(); err != nil {
+		log.Debugf("Failed to close websocket of %s: %v", commonName, err)
+	}
+}
+
+// parseReplayCursor returns the sequence number from the "since" query
+// parameter of the request and whether it was given
+func parseReplayCursor(r *http.Request) (uint64, bool, error) {
+	param := r.URL.Query().Get("since")
+	if param == "" {
+		return 0, false, nil
+	}
+	since, err := strconv.ParseUint(param, 10, 64)
+	if err != nil {
+		return 0, false, errors.New("400: Invalid since parameter")
+	}
+	return since, true, nil
+}
+
+// closeConsumerConnection closes the websocket connection or ends the gRPC
+// stream of a consumer that opened a new notifications connection
+func closeConsumerConnection(conn ConsumerConnection) {
+	if conn.closeStream != nil {
+		conn.closeStream(status.Error(codes.Aborted,
+			"replaced by a new notifications connection"))
+		return
+	}
+	if conn.connection == nil {
+		return
+	}
+
+	msgType := websocket.CloseMessage
+	closeMessage := websocket.FormatCloseMessage(
+		websocket.CloseServiceRestart,
+		"New connection request, closing this connection")
+	err := conn.connection.WriteMessage(msgType, closeMessage)
+	if err != nil {
+		log.Info("Failed to send close message to old connection")
+	}
+	err = conn.connection.Close()
+	if err != nil {
+		log.Info("Failed to close previous websocket connection")
+	}
 }
 
 // getConsumerSubscriptions returns a list of subscriptions belonging
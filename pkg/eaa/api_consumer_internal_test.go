@@ -0,0 +1,171 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package eaa
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	g "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/open-ness/edgenode/pkg/util"
+)
+
+var _ = g.Describe("watchWsConn", func() {
+	const consumer = "ns:consumer"
+
+	var (
+		eaaCtx *Context
+		server *httptest.Server
+		client *websocket.Conn
+	)
+
+	connected := func() bool {
+		eaaCtx.consumerConnections.RLock()
+		defer eaaCtx.consumerConnections.RUnlock()
+		_, found := eaaCtx.consumerConnections.m[consumer]
+		return found
+	}
+
+	g.BeforeEach(func() {
+		eaaCtx = &Context{}
+		eaaCtx.consumerConnections = consumerConns{m: make(map[string]ConsumerConnection)}
+		eaaCtx.cfg.Keepalive = KeepaliveConfig{
+			PingInterval: util.Duration{Duration: 50 * time.Millisecond},
+			IdleTimeout:  util.Duration{Duration: 200 * time.Millisecond},
+		}
+
+		server = httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				conn, err := socket.Upgrade(w, r, nil)
+				if err != nil {
+					return
+				}
+				eaaCtx.consumerConnections.Lock()
+				eaaCtx.consumerConnections.m[consumer] = ConsumerConnection{
+					connection: conn}
+				eaaCtx.consumerConnections.Unlock()
+				go watchWsConn(conn, consumer, nil, eaaCtx)
+			}))
+
+		var err error
+		client, _, err = websocket.DefaultDialer.Dial(
+			"ws"+strings.TrimPrefix(server.URL, "http"), nil)
+		Expect(err).ShouldNot(HaveOccurred())
+		Eventually(connected).Should(BeTrue())
+	})
+
+	g.AfterEach(func() {
+		client.Close()
+		server.Close()
+	})
+
+	g.It("keeps the connection of a consumer answering pings", func() {
+		// Reading answers pings with pongs
+		go func() {
+			for {
+				if _, _, err := client.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		Consistently(connected, 500*time.Millisecond).Should(BeTrue())
+	})
+
+	g.It("drops the connection of an idle consumer", func() {
+		Eventually(connected, time.Second).Should(BeFalse())
+	})
+
+	g.It("drops the connection closed by the consumer", func() {
+		Expect(client.WriteMessage(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))).
+			To(Succeed())
+		Eventually(connected, 150*time.Millisecond).Should(BeFalse())
+	})
+})
+
+var _ = g.Describe("createWsConn", func() {
+	const consumer = "ns:consumer"
+
+	var (
+		eaaCtx  *Context
+		server  *httptest.Server
+		handled chan struct{}
+	)
+
+	notification := func(seq uint64, size int) []byte {
+		payload, err := json.Marshal(NotificationToConsumer{
+			Name:     "event",
+			Version:  "1.0.0",
+			Payload:  json.RawMessage(`"` + strings.Repeat("x", size) + `"`),
+			Sequence: seq,
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+		return payload
+	}
+
+	g.BeforeEach(func() {
+		eaaCtx = &Context{}
+		eaaCtx.consumerConnections = consumerConns{m: make(map[string]ConsumerConnection)}
+		eaaCtx.notificationBuffer = newNotificationStore(
+			NotificationBufferConfig{Size: 10})
+
+		handled = make(chan struct{})
+		server = httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				defer close(handled)
+				ctx := context.WithValue(r.Context(),
+					contextKey("appliance-ctx"), eaaCtx)
+				ctx = context.WithValue(ctx, clientCommonNameKey, consumer)
+				code, _, err := createWsConn(w, r.WithContext(ctx))
+				if err != nil {
+					w.WriteHeader(code)
+				}
+			}))
+	})
+
+	g.AfterEach(func() {
+		server.Close()
+	})
+
+	g.It("replays missed notifications without holding up others", func() {
+		// Replayed notifications don't fit in socket buffers of a consumer
+		// that doesn't read yet
+		const size = 1024 * 1024
+		for seq := uint64(1); seq <= 8; seq++ {
+			eaaCtx.notificationBuffer.add(consumer, seq,
+				notification(seq, size))
+		}
+
+		client, _, err := websocket.DefaultDialer.Dial(
+			"ws"+strings.TrimPrefix(server.URL, "http")+"?since=2",
+			http.Header{"Host": []string{consumer}})
+		Expect(err).ShouldNot(HaveOccurred())
+		defer client.Close()
+
+		Eventually(handled).Should(BeClosed())
+		locked := make(chan struct{})
+		go func() {
+			eaaCtx.consumerConnections.Lock()
+			eaaCtx.consumerConnections.Unlock()
+			close(locked)
+		}()
+		Eventually(locked).Should(BeClosed())
+		Expect(sendNotificationToSubscriber(consumer, 9, notification(9, 1),
+			eaaCtx)).To(Succeed())
+
+		for seq := uint64(3); seq <= 9; seq++ {
+			var notif NotificationToConsumer
+			Expect(client.ReadJSON(&notif)).To(Succeed())
+			Expect(notif.Sequence).To(Equal(seq))
+		}
+	})
+})
@@ -18,44 +18,37 @@ func DeregisterApplication(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 
-	clientCert := r.TLS.PeerCertificates[0]
-	commonName := clientCert.Subject.CommonName
+	commonName := clientCommonName(r)
 	URN, err := CommonNameStringToURN(commonName)
 	if err != nil {
 		log.Errf("Error during converting Common Name to URN: %s", err.Error())
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, http.StatusUnauthorized, ErrorCodeUnauthorized,
+			err.Error())
 		return
 	}
 
 	// Check preemptively if a Service exists to return the HTTP code that is more likely to be
 	// correct
-	statusCode := http.StatusNoContent
-	if !isServicePresent(commonName, eaaCtx) {
-		statusCode = http.StatusNotFound
-	}
+	found := isServicePresent(commonName, eaaCtx)
 
 	// Prepare Service structure
 	var serv Service
 	serv.URN = &URN
-	svcMsg := ServiceMessage{Svc: &serv, Action: serviceActionDeregister}
 
-	// Create Watermill Message and publish it
-	data, err := json.Marshal(svcMsg)
+	err = publishServiceMessage(commonName, &serv, serviceActionDeregister, eaaCtx)
 	if err != nil {
-		log.Errf("Error during Service structure marshaling: %s", err.Error())
-		w.WriteHeader(http.StatusInternalServerError)
+		log.Errf("Deregister Application: %s", err.Error())
+		writeError(w, r, http.StatusInternalServerError, ErrorCodeInternal,
+			err.Error())
 		return
 	}
-	msg := message.NewMessage(commonName, data)
 
-	err = eaaCtx.MsgBrokerCtx.publish(servicesTopic, msg)
-	if err != nil {
-		log.Errf("Error during Message publishing: %s", err.Error())
-		w.WriteHeader(http.StatusInternalServerError)
+	if !found {
+		writeError(w, r, http.StatusNotFound, ErrorCodeNotFound,
+			"service is not registered")
 		return
 	}
-
-	w.WriteHeader(statusCode)
+	w.WriteHeader(http.StatusNoContent)
 
 	log.Debugf("Successfully processed DeregisterApplication from %s",
 		commonName)
@@ -66,76 +59,106 @@ func GetNotifications(w http.ResponseWriter, r *http.Request) {
 	eaaCtx := r.Context().Value(contextKey("appliance-ctx")).(*Context)
 
 	eaaCtx.serviceInfo.RLock()
-	if eaaCtx.serviceInfo.m == nil {
-		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
-		w.WriteHeader(http.StatusInternalServerError)
-	}
+	initialized := eaaCtx.serviceInfo.m != nil
 	eaaCtx.serviceInfo.RUnlock()
+	if !initialized {
+		writeError(w, r, http.StatusInternalServerError, ErrorCodeInternal,
+			"EAA context is not initialized")
+		return
+	}
 
-	statCode, err := createWsConn(w, r)
+	statCode, errCode, err := createWsConn(w, r)
 	if err != nil {
 		log.Errf("Error in WebSocket Connection Creation: %#v", err)
 		if statCode != 0 {
-			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
-			w.WriteHeader(statCode)
+			writeError(w, r, statCode, errCode, err.Error())
 		}
 		return
 	}
 
 	// Subscribe to the Client topic to receive all of its subscriptions
-	topic := getClientTopicName(r.TLS.PeerCertificates[0].Subject.CommonName)
+	topic := getClientTopicName(clientCommonName(r))
 	err = eaaCtx.MsgBrokerCtx.addSubscriber(clientSubscriber, topic, r)
 	if err != nil {
 		// Ignore objectAlreadyExistsError error
 		if _, ok := err.(objectAlreadyExistsError); !ok {
 			log.Errf("Error when adding a Subscriber of type: '%v', topic: '%v'", clientSubscriber,
 				topic)
-			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
-			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
 	}
 
 	log.Debugf("Successfully processed GetNotifications from %s",
-		r.TLS.PeerCertificates[0].Subject.CommonName)
+		clientCommonName(r))
+}
+
+// GetDeliveryStatistics implements https API
+func GetDeliveryStatistics(w http.ResponseWriter, r *http.Request) {
+	eaaCtx := r.Context().Value(contextKey("appliance-ctx")).(*Context)
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	commonName := clientCommonName(r)
+	URN, err := CommonNameStringToURN(commonName)
+	if err != nil {
+		log.Errf("Error during URN generation: %s", err.Error())
+		writeError(w, r, http.StatusUnauthorized, ErrorCodeUnauthorized,
+			err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	err = json.NewEncoder(w).Encode(eaaCtx.deliveryStats.get(URN.String()))
+	if err != nil {
+		log.Errf("Delivery Statistics Getter: %s", err.Error())
+		return
+	}
+
+	log.Debugf("Successfully processed GetDeliveryStatistics from %s",
+		commonName)
 }
 
 // GetServices implements https API
 func GetServices(w http.ResponseWriter, r *http.Request) {
-	var servList ServiceList
 	eaaCtx := r.Context().Value(contextKey("appliance-ctx")).(*Context)
 
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
-	w.WriteHeader(http.StatusOK)
+
+	query, err := parseServiceQuery(r.URL.Query())
+	if err != nil {
+		log.Errf("Get Services: %s", err.Error())
+		writeError(w, r, http.StatusBadRequest, ErrorCodeInvalidRequest,
+			err.Error())
+		return
+	}
 
 	eaaCtx.serviceInfo.RLock()
 	defer eaaCtx.serviceInfo.RUnlock()
 
 	if eaaCtx.serviceInfo.m == nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, http.StatusInternalServerError, ErrorCodeInternal,
+			"EAA context is not initialized")
 		return
 	}
 
-	for _, serv := range eaaCtx.serviceInfo.m {
-		servList.Services = append(servList.Services, serv)
-	}
+	w.WriteHeader(http.StatusOK)
+
+	commonName := clientCommonName(r)
+	servList := queryServices(commonName, query, eaaCtx)
 
 	encoder := json.NewEncoder(w)
-	err := encoder.Encode(servList)
+	err = encoder.Encode(servList)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		log.Errf("Get Services: %s", err.Error())
 		return
 	}
 
-	log.Debugf("Successfully processed GetServices from %s",
-		r.TLS.PeerCertificates[0].Subject.CommonName)
+	log.Debugf("Successfully processed GetServices from %s", commonName)
 }
 
 // GetSubscriptions implements https API
 func GetSubscriptions(w http.ResponseWriter, r *http.Request) {
 	eaaCtx := r.Context().Value(contextKey("appliance-ctx")).(*Context)
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
-	w.WriteHeader(http.StatusOK)
 
 	var (
 		subs       *SubscriptionList
@@ -143,17 +166,18 @@ func GetSubscriptions(w http.ResponseWriter, r *http.Request) {
 		err        error
 	)
 
-	commonName = r.TLS.PeerCertificates[0].Subject.CommonName
+	commonName = clientCommonName(r)
 
 	if subs, err = getConsumerSubscriptions(commonName, eaaCtx); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, http.StatusInternalServerError, ErrorCodeInternal,
+			err.Error())
 		log.Errf("Consumer Subscription List Getter: %s",
 			err.Error())
 		return
 	}
 
+	w.WriteHeader(http.StatusOK)
 	if err = json.NewEncoder(w).Encode(*subs); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
 		log.Errf("Consumer Subscription List Getter: %s",
 			err.Error())
 		return
@@ -171,15 +195,23 @@ func PushNotificationToSubscribers(w http.ResponseWriter, r *http.Request) {
 	err := json.NewDecoder(r.Body).Decode(&notif)
 	if err != nil {
 		log.Errf("Error in Publish Notification: %s", err.Error())
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, http.StatusBadRequest,
+			ErrorCodeInvalidRequest, err.Error())
 		return
 	}
 
-	commonName := r.TLS.PeerCertificates[0].Subject.CommonName
+	commonName := clientCommonName(r)
 	URN, err := CommonNameStringToURN(commonName)
 	if err != nil {
 		log.Errf("Error during URN generation: %s", err.Error())
-		w.WriteHeader(http.StatusUnauthorized)
+		writeError(w, r, http.StatusUnauthorized, ErrorCodeUnauthorized,
+			err.Error())
+		return
+	}
+
+	if !eaaCtx.limiter.allowNotification(commonName) {
+		writeError(w, r, http.StatusTooManyRequests, ErrorCodeRateLimited,
+			"notification rate limit exceeded")
 		return
 	}
 
@@ -187,41 +219,33 @@ func PushNotificationToSubscribers(w http.ResponseWriter, r *http.Request) {
 	eaaCtx.serviceInfo.RLock()
 	defer eaaCtx.serviceInfo.RUnlock()
 
-	_, serviceFound := eaaCtx.serviceInfo.m[commonName]
+	serv, serviceFound := eaaCtx.serviceInfo.m[commonName]
 	if !serviceFound {
 		log.Err("Producer is not registered")
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, http.StatusNotFound,
+			ErrorCodeProducerNotRegistered, "producer is not registered")
 		return
 	}
 
-	notifTopic := getNotificationTopicName(URN.Namespace)
-
-	// Add a Publisher to the Notification Namespace topic (if not subscribed already)
-	err = eaaCtx.MsgBrokerCtx.addPublisher(notificationPublisher, notifTopic, r)
-	if err != nil {
-		// Ignore objectAlreadyExistsError error
-		if _, ok := err.(objectAlreadyExistsError); !ok {
-			log.Errf("Error when adding a Publisher of type: '%v', id: '%v'. Error: %s",
-				notificationPublisher, notifTopic, err.Error())
-		}
+	if err = checkPayloadSize(&notif, eaaCtx); err != nil {
+		log.Errf("Invalid notification from %s: %s", commonName, err.Error())
+		writeError(w, r, http.StatusRequestEntityTooLarge,
+			ErrorCodePayloadTooLarge, err.Error())
+		return
 	}
 
-	// Prepare NotificationMessage that will be published using a Message Broker
-	notifMsg := NotificationMessage{Notification: &notif, URN: &URN}
-
-	// Create Watermill Message and publish it
-	data, err := json.Marshal(notifMsg)
-	if err != nil {
-		log.Errf("Error during Service structure marshaling: %s", err.Error())
-		w.WriteHeader(http.StatusInternalServerError)
+	if err = validateNotificationPayload(&serv, &notif); err != nil {
+		log.Errf("Invalid notification from %s: %s", commonName, err.Error())
+		writeError(w, r, http.StatusBadRequest, ErrorCodeInvalidPayload,
+			err.Error())
 		return
 	}
-	msg := message.NewMessage(commonName, data)
 
-	err = eaaCtx.MsgBrokerCtx.publish(notifTopic, msg)
+	err = publishNotification(commonName, &URN, &notif, r, eaaCtx)
 	if err != nil {
-		log.Errf("Error during Message publishing: %s", err.Error())
-		w.WriteHeader(http.StatusInternalServerError)
+		log.Errf("Error in Publish Notification: %s", err.Error())
+		writeError(w, r, http.StatusInternalServerError, ErrorCodeInternal,
+			err.Error())
 		return
 	}
 
@@ -236,13 +260,27 @@ func RegisterApplication(w http.ResponseWriter, r *http.Request) {
 	eaaCtx := r.Context().Value(contextKey("appliance-ctx")).(*Context)
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 
-	clientCert := r.TLS.PeerCertificates[0]
-	commonName := clientCert.Subject.CommonName
+	commonName := clientCommonName(r)
+
+	if !eaaCtx.cfg.AccessControl.allowed(commonName, accessActionRegister,
+		commonName) {
+		writeError(w, r, http.StatusForbidden, ErrorCodeForbidden,
+			"registration is not allowed")
+		return
+	}
 
 	err := json.NewDecoder(r.Body).Decode(&serv)
 	if err != nil {
 		log.Errf("Register Application: %s", err.Error())
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, http.StatusBadRequest,
+			ErrorCodeInvalidRequest, err.Error())
+		return
+	}
+
+	if err = checkNotificationSchemas(&serv); err != nil {
+		log.Errf("Register Application: %s", err.Error())
+		writeError(w, r, http.StatusBadRequest, ErrorCodeInvalidPayload,
+			err.Error())
 		return
 	}
 
@@ -250,27 +288,18 @@ func RegisterApplication(w http.ResponseWriter, r *http.Request) {
 	var URN URN
 	if URN, err = CommonNameStringToURN(commonName); err != nil {
 		log.Errf("Error during URN generation: %s", err.Error())
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, http.StatusUnauthorized,
+			ErrorCodeUnauthorized, err.Error())
 		return
 	}
 	serv.URN = &URN
+	serv.Node = ""
 
-	// Prepare ServiceMessage that will be published using a Message Broker
-	svcMsg := ServiceMessage{Svc: &serv, Action: serviceActionRegister}
-
-	// Create Watermill Message and publish it
-	data, err := json.Marshal(svcMsg)
-	if err != nil {
-		log.Errf("Error during Service structure marshaling: %s", err.Error())
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-	msg := message.NewMessage(commonName, data)
-
-	err = eaaCtx.MsgBrokerCtx.publish(servicesTopic, msg)
+	err = publishServiceMessage(commonName, &serv, serviceActionRegister, eaaCtx)
 	if err != nil {
-		log.Errf("Error during Message publishing: %s", err.Error())
-		w.WriteHeader(http.StatusInternalServerError)
+		log.Errf("Register Application: %s", err.Error())
+		writeError(w, r, http.StatusInternalServerError, ErrorCodeInternal,
+			err.Error())
 		return
 	}
 
@@ -288,23 +317,39 @@ func SubscribeNamespaceNotifications(w http.ResponseWriter, r *http.Request) {
 
 	err := json.NewDecoder(r.Body).Decode(&sub)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, http.StatusBadRequest,
+			ErrorCodeInvalidRequest, err.Error())
 		log.Errf("Namespace Notification Registration: %s",
 			err.Error())
 		return
 	}
 
-	commonName := r.TLS.PeerCertificates[0].Subject.CommonName
+	commonName := clientCommonName(r)
 
 	// Get the Notification Namespace
 	namespace := mux.Vars(r)["urn.namespace"]
 	urn := URN{Namespace: namespace}
 
+	if err = checkSubscriptionPatterns(&urn, sub); err != nil {
+		log.Errf("Invalid subscription of %s: %s", commonName, err.Error())
+		writeError(w, r, http.StatusBadRequest, ErrorCodeInvalidRequest,
+			err.Error())
+		return
+	}
+
+	if !eaaCtx.cfg.AccessControl.allowed(commonName, accessActionSubscribe,
+		namespace+":*") {
+		writeError(w, r, http.StatusForbidden, ErrorCodeForbidden,
+			"subscription is not allowed")
+		return
+	}
+
 	err = processSubscriptionRequest(subscriptionActionSubscribe, subscriptionScopeNamespace,
 		commonName, &urn, sub, r, eaaCtx)
 	if err != nil {
 		log.Errf("Error during Namespace Subscription Request processing: %s", err.Error())
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, http.StatusInternalServerError, ErrorCodeInternal,
+			err.Error())
 		return
 	}
 
@@ -322,12 +367,13 @@ func SubscribeServiceNotifications(w http.ResponseWriter, r *http.Request) {
 
 	err := json.NewDecoder(r.Body).Decode(&sub)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, http.StatusBadRequest,
+			ErrorCodeInvalidRequest, err.Error())
 		log.Errf("Service Notification Registration: %s", err.Error())
 		return
 	}
 
-	commonName := r.TLS.PeerCertificates[0].Subject.CommonName
+	commonName := clientCommonName(r)
 
 	// Get the Notification Namespace and Service ID
 	vars := mux.Vars(r)
@@ -335,11 +381,26 @@ func SubscribeServiceNotifications(w http.ResponseWriter, r *http.Request) {
 	serviceID := vars["urn.id"]
 	urn := URN{Namespace: namespace, ID: serviceID}
 
+	if err = checkSubscriptionPatterns(&urn, sub); err != nil {
+		log.Errf("Invalid subscription of %s: %s", commonName, err.Error())
+		writeError(w, r, http.StatusBadRequest, ErrorCodeInvalidRequest,
+			err.Error())
+		return
+	}
+
+	if !eaaCtx.cfg.AccessControl.allowed(commonName, accessActionSubscribe,
+		urn.String()) {
+		writeError(w, r, http.StatusForbidden, ErrorCodeForbidden,
+			"subscription is not allowed")
+		return
+	}
+
 	err = processSubscriptionRequest(subscriptionActionSubscribe, subscriptionScopeService,
 		commonName, &urn, sub, r, eaaCtx)
 	if err != nil {
 		log.Errf("Error during Service Subscription Request processing: %s", err.Error())
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, http.StatusInternalServerError, ErrorCodeInternal,
+			err.Error())
 		return
 	}
 
@@ -353,13 +414,14 @@ func UnsubscribeAllNotifications(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 	eaaCtx := r.Context().Value(contextKey("appliance-ctx")).(*Context)
 
-	commonName := r.TLS.PeerCertificates[0].Subject.CommonName
+	commonName := clientCommonName(r)
 
 	err := processSubscriptionRequest(subscriptionActionUnsubscribe, subscriptionScopeAll,
 		commonName, nil, nil, r, eaaCtx)
 	if err != nil {
 		log.Errf("Error during All Unsubscription Request processing: %s", err.Error())
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, http.StatusInternalServerError, ErrorCodeInternal,
+			err.Error())
 		return
 	}
 
@@ -376,13 +438,14 @@ func UnsubscribeNamespaceNotifications(w http.ResponseWriter, r *http.Request) {
 
 	err := json.NewDecoder(r.Body).Decode(&sub)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, http.StatusBadRequest,
+			ErrorCodeInvalidRequest, err.Error())
 		log.Errf("Namespace Notification Unregistration: %s",
 			err.Error())
 		return
 	}
 
-	commonName := r.TLS.PeerCertificates[0].Subject.CommonName
+	commonName := clientCommonName(r)
 
 	// Get the Notification Namespace
 	namespace := mux.Vars(r)["urn.namespace"]
@@ -392,7 +455,8 @@ func UnsubscribeNamespaceNotifications(w http.ResponseWriter, r *http.Request) {
 		commonName, &urn, sub, r, eaaCtx)
 	if err != nil {
 		log.Errf("Error during Namespace Unsubscription Request processing: %s", err.Error())
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, http.StatusInternalServerError, ErrorCodeInternal,
+			err.Error())
 		return
 	}
 
@@ -409,12 +473,13 @@ func UnsubscribeServiceNotifications(w http.ResponseWriter, r *http.Request) {
 
 	err := json.NewDecoder(r.Body).Decode(&sub)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, http.StatusBadRequest,
+			ErrorCodeInvalidRequest, err.Error())
 		log.Errf("Service Notification Unregistration: %s", err.Error())
 		return
 	}
 
-	commonName := r.TLS.PeerCertificates[0].Subject.CommonName
+	commonName := clientCommonName(r)
 
 	// Get the Notification Namespace and Service ID
 	vars := mux.Vars(r)
@@ -426,7 +491,8 @@ func UnsubscribeServiceNotifications(w http.ResponseWriter, r *http.Request) {
 		commonName, &urn, sub, r, eaaCtx)
 	if err != nil {
 		log.Errf("Error during Service Unsubscription Request processing: %s", err.Error())
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, http.StatusInternalServerError, ErrorCodeInternal,
+			err.Error())
 		return
 	}
 
@@ -435,6 +501,64 @@ func UnsubscribeServiceNotifications(w http.ResponseWriter, r *http.Request) {
 		commonName)
 }
 
+// publishServiceMessage publishes a ServiceMessage with the Service
+// (de)registration to the Services topic
+func publishServiceMessage(commonName string, serv *Service, action string,
+	eaaCtx *Context) error {
+
+	// Prepare ServiceMessage that will be published using a Message Broker
+	svcMsg := ServiceMessage{Svc: serv, Action: action}
+
+	// Create Watermill Message and publish it
+	data, err := json.Marshal(svcMsg)
+	if err != nil {
+		return errors.Wrap(err, "Error during Service structure marshaling")
+	}
+	msg := message.NewMessage(commonName, data)
+
+	err = eaaCtx.MsgBrokerCtx.publish(servicesTopic, msg)
+	if err != nil {
+		return errors.Wrap(err, "Error during Message publishing")
+	}
+
+	return nil
+}
+
+// publishNotification publishes a NotificationMessage of the producer to its
+// Namespace Notification topic
+func publishNotification(commonName string, URN *URN,
+	notif *NotificationFromProducer, r *http.Request, eaaCtx *Context) error {
+
+	notifTopic := getNotificationTopicName(URN.Namespace)
+
+	// Add a Publisher to the Notification Namespace topic (if not subscribed already)
+	err := eaaCtx.MsgBrokerCtx.addPublisher(notificationPublisher, notifTopic, r)
+	if err != nil {
+		// Ignore objectAlreadyExistsError error
+		if _, ok := err.(objectAlreadyExistsError); !ok {
+			log.Errf("Error when adding a Publisher of type: '%v', id: '%v'. Error: %s",
+				notificationPublisher, notifTopic, err.Error())
+		}
+	}
+
+	// Prepare NotificationMessage that will be published using a Message Broker
+	notifMsg := NotificationMessage{Notification: notif, URN: URN}
+
+	// Create Watermill Message and publish it
+	data, err := json.Marshal(notifMsg)
+	if err != nil {
+		return errors.Wrap(err, "Error during Notification structure marshaling")
+	}
+	msg := message.NewMessage(commonName, data)
+
+	err = eaaCtx.MsgBrokerCtx.publish(notifTopic, msg)
+	if err != nil {
+		return errors.Wrap(err, "Error during Message publishing")
+	}
+
+	return nil
+}
+
 // processSubscriptionRequest adds Publisher and Subscriber to the Client topic and publishes the
 // SubscriptionMessage to it.
 // If subscriptionAction == subscriptionActionRegister it also subscribes to the
@@ -117,6 +117,14 @@ func sortSubscriptionSlices(slices ...[]eaa.Subscription) {
 	}
 }
 
+// expectErrorResponse checks the status and the error code of the response
+func expectErrorResponse(resp *http.Response, status, code string) {
+	Expect(resp.Status).To(Equal(status))
+	var errResp eaa.ErrorResponse
+	Expect(json.NewDecoder(resp.Body).Decode(&errResp)).To(Succeed())
+	Expect(errResp.Code).To(Equal(code))
+}
+
 // registerProducer sends a registration POST request to the EAA
 func registerProducer(c *http.Client, service eaa.Service,
 	subject string) {
@@ -135,7 +143,7 @@ func registerProducer(c *http.Client, service eaa.Service,
 	Expect(respPost.Status).To(Equal("200 OK"))
 }
 
-// registerProducerErr sends a registration POST request to the EAA and expects Internal Server Error
+// registerProducerErr sends a registration POST request to the EAA and expects Unauthorized
 func registerProducerErr(c *http.Client, service eaa.Service) {
 	By("Service struct list encoding")
 	payload, err := json.Marshal(service)
@@ -149,7 +157,7 @@ func registerProducerErr(c *http.Client, service eaa.Service) {
 
 	By("Comparing POST response code")
 	defer respPost.Body.Close()
-	Expect(respPost.Status).To(Equal("500 Internal Server Error"))
+	expectErrorResponse(respPost, "401 Unauthorized", eaa.ErrorCodeUnauthorized)
 }
 
 // registerProducerWithBadRequest sends a registration POST request to the EAA
@@ -162,7 +170,7 @@ func registerProducerWithBadRequest(c *http.Client) {
 
 	By("Comparing POST response code")
 	defer respPost.Body.Close()
-	Expect(respPost.Status).To(Equal("500 Internal Server Error"))
+	expectErrorResponse(respPost, "400 Bad Request", eaa.ErrorCodeInvalidRequest)
 }
 
 // deregisterProducer sends a deregistration DELETE request to the EAA
@@ -221,7 +229,7 @@ func subscribeConsumerBadRequest(c *http.Client, path string) {
 
 	By("Comparing POST response code")
 	defer respPost.Body.Close()
-	Expect(respPost.Status).To(Equal("500 Internal Server Error"))
+	expectErrorResponse(respPost, "400 Bad Request", eaa.ErrorCodeInvalidRequest)
 }
 
 // unsubscribeConsumer sends a consumer subscription DELETE request
@@ -256,7 +264,7 @@ func unsubscribeConsumerWithBadRequest(c *http.Client, path string) {
 
 	By("Comparing DELETE response code")
 	defer respPost.Body.Close()
-	Expect(respPost.Status).To(Equal("500 Internal Server Error"))
+	expectErrorResponse(respPost, "400 Bad Request", eaa.ErrorCodeInvalidRequest)
 }
 
 // unsubscribeAll sends a all consumer subscription DELETE request
@@ -332,7 +340,7 @@ func produceEventWithUnregisteredProducer(c *http.Client, notif eaa.Notification
 
 	By("Comparing POST response code")
 	defer respPost.Body.Close()
-	Expect(respPost.Status).To(Equal("500 Internal Server Error"))
+	expectErrorResponse(respPost, "404 Not Found", eaa.ErrorCodeProducerNotRegistered)
 }
 
 // produceEventWithBadRequest sends a notification POST request to the EAA
@@ -346,7 +354,7 @@ func produceEventWithBadRequest(c *http.Client) {
 
 	By("Comparing POST response code")
 	defer respPost.Body.Close()
-	Expect(respPost.Status).To(Equal("500 Internal Server Error"))
+	expectErrorResponse(respPost, "400 Bad Request", eaa.ErrorCodeInvalidRequest)
 }
 
 // produceEventWithBadCommonName sends a notification POST request to the EAA
@@ -364,7 +372,7 @@ func produceEventWithBadCommonName(c *http.Client, notif eaa.NotificationFromPro
 
 	By("Comparing POST response code")
 	defer respPost.Body.Close()
-	Expect(respPost.Status).To(Equal("401 Unauthorized"))
+	expectErrorResponse(respPost, "401 Unauthorized", eaa.ErrorCodeUnauthorized)
 }
 
 // getServiceList sends a GET request to the EAA and retrieves
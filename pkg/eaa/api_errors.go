@@ -0,0 +1,108 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package eaa
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// correlationIDHeader carries the correlation ID of a request. EAA uses the
+// one sent by the client or generates a new one, returns it in responses of
+// routed requests and logs it in error and audit records.
+const correlationIDHeader = "X-Correlation-ID"
+
+// Error codes returned in ErrorResponse. The codes are stable and clients
+// may rely on them, unlike on the messages.
+const (
+	// ErrorCodeInvalidRequest - the request body or parameters are malformed
+	ErrorCodeInvalidRequest = "INVALID_REQUEST"
+	// ErrorCodeInvalidPayload - a service or notification does not match
+	// its schema
+	ErrorCodeInvalidPayload = "INVALID_PAYLOAD"
+	// ErrorCodeUnauthorized - the client certificate does not identify an
+	// application
+	ErrorCodeUnauthorized = "UNAUTHORIZED"
+	// ErrorCodeForbidden - the access control policy denies the request
+	ErrorCodeForbidden = "FORBIDDEN"
+	// ErrorCodeNotFound - the service of the client is not registered
+	ErrorCodeNotFound = "NOT_FOUND"
+	// ErrorCodeProducerNotRegistered - a notification is pushed by
+	// a producer that is not registered
+	ErrorCodeProducerNotRegistered = "PRODUCER_NOT_REGISTERED"
+	// ErrorCodePayloadTooLarge - the notification payload exceeds the
+	// configured limit
+	ErrorCodePayloadTooLarge = "PAYLOAD_TOO_LARGE"
+	// ErrorCodeAcksDisabled - acknowledged delivery is requested while not
+	// enabled
+	ErrorCodeAcksDisabled = "ACKS_DISABLED"
+	// ErrorCodeRateLimited - the client exceeded its rate limits, the
+	// request may be retried later
+	ErrorCodeRateLimited = "RATE_LIMITED"
+	// ErrorCodeInternal - EAA failed to process the request, it may be
+	// retried
+	ErrorCodeInternal = "INTERNAL"
+)
+
+// retryableErrorCodes are codes of errors which may succeed when retried
+var retryableErrorCodes = map[string]bool{
+	ErrorCodeRateLimited: true,
+	ErrorCodeInternal:    true,
+}
+
+// ErrorResponse is the body of an EAA error response
+type ErrorResponse struct {
+	// Code is one of the stable ErrorCode values
+	Code string `json:"code"`
+	// Message describes the error for humans
+	Message string `json:"message"`
+	// CorrelationID identifies the request in EAA logs
+	CorrelationID string `json:"correlation_id"`
+	// Retryable tells if the request may succeed when retried
+	Retryable bool `json:"retryable"`
+}
+
+// correlationID returns the correlation ID of the request
+func correlationID(r *http.Request) string {
+	if id := r.Header.Get(correlationIDHeader); id != "" {
+		return id
+	}
+	return uuid.New().String()
+}
+
+// correlationMiddleware sets the correlation ID of the request, so that
+// later handlers and the audit log use the same one, and returns it in the
+// response
+func correlationMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := correlationID(r)
+		r.Header.Set(correlationIDHeader, id)
+		w.Header().Set(correlationIDHeader, id)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// writeError writes an ErrorResponse with the HTTP status code and logs it
+// with the correlation ID
+func writeError(w http.ResponseWriter, r *http.Request, statusCode int,
+	code string, message string) {
+
+	resp := ErrorResponse{
+		Code:          code,
+		Message:       message,
+		CorrelationID: correlationID(r),
+		Retryable:     retryableErrorCodes[code],
+	}
+	log.Debugf("Request %s %s [%s] failed: %s: %s", r.Method, r.URL.Path,
+		resp.CorrelationID, code, message)
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.Header().Set(correlationIDHeader, resp.CorrelationID)
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Errf("Failed to encode error response: %s", err.Error())
+	}
+}
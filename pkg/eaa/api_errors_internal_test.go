@@ -0,0 +1,127 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package eaa
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	g "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = g.Describe("writeError", func() {
+	decode := func(rec *httptest.ResponseRecorder) ErrorResponse {
+		var resp ErrorResponse
+		Expect(json.NewDecoder(rec.Body).Decode(&resp)).To(Succeed())
+		return resp
+	}
+
+	g.It("returns the correlation ID of the request", func() {
+		req := httptest.NewRequest("POST", "/notifications", nil)
+		req.Header.Set(correlationIDHeader, "req-1")
+		rec := httptest.NewRecorder()
+
+		writeError(rec, req, http.StatusTooManyRequests, ErrorCodeRateLimited,
+			"slow down")
+
+		Expect(rec.Code).To(Equal(http.StatusTooManyRequests))
+		Expect(rec.Header().Get("Content-Type")).To(
+			HavePrefix("application/json"))
+		Expect(rec.Header().Get(correlationIDHeader)).To(Equal("req-1"))
+		Expect(decode(rec)).To(Equal(ErrorResponse{
+			Code:          ErrorCodeRateLimited,
+			Message:       "slow down",
+			CorrelationID: "req-1",
+			Retryable:     true,
+		}))
+	})
+
+	g.It("generates a correlation ID", func() {
+		req := httptest.NewRequest("GET", "/services", nil)
+		rec := httptest.NewRecorder()
+
+		writeError(rec, req, http.StatusForbidden, ErrorCodeForbidden,
+			"denied")
+
+		resp := decode(rec)
+		Expect(resp.Code).To(Equal(ErrorCodeForbidden))
+		Expect(resp.Retryable).To(BeFalse())
+		Expect(resp.CorrelationID).ToNot(BeEmpty())
+		Expect(rec.Header().Get(correlationIDHeader)).To(
+			Equal(resp.CorrelationID))
+	})
+})
+
+var _ = g.Describe("correlationMiddleware", func() {
+	g.It("returns the correlation ID in every response", func() {
+		var handled string
+		handler := correlationMiddleware(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				handled = r.Header.Get(correlationIDHeader)
+			}))
+
+		req := httptest.NewRequest("GET", "/services", nil)
+		req.Header.Set(correlationIDHeader, "req-1")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Header().Get(correlationIDHeader)).To(Equal("req-1"))
+		Expect(handled).To(Equal("req-1"))
+
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "/services", nil))
+		Expect(handled).ToNot(BeEmpty())
+		Expect(rec.Header().Get(correlationIDHeader)).To(Equal(handled))
+	})
+})
+
+var _ = g.Describe("REST handlers", func() {
+	g.It("return the status matching the error code", func() {
+		eaaCtx := &Context{}
+		eaaCtx.serviceInfo.m = make(map[string]Service)
+
+		for name, tc := range map[string]struct {
+			handler    http.HandlerFunc
+			body       string
+			commonName string
+			status     int
+			code       string
+		}{
+			"register with bad body": {RegisterApplication, "---", "ns:app",
+				http.StatusBadRequest, ErrorCodeInvalidRequest},
+			"register with bad common name": {RegisterApplication, "{}",
+				"app", http.StatusUnauthorized, ErrorCodeUnauthorized},
+			"deregister with bad common name": {DeregisterApplication, "",
+				"app", http.StatusUnauthorized, ErrorCodeUnauthorized},
+			"push with bad body": {PushNotificationToSubscribers, "---",
+				"ns:app", http.StatusBadRequest, ErrorCodeInvalidRequest},
+			"push with bad common name": {PushNotificationToSubscribers,
+				"{}", "app", http.StatusUnauthorized, ErrorCodeUnauthorized},
+			"push by unregistered producer": {PushNotificationToSubscribers,
+				"{}", "ns:app", http.StatusNotFound,
+				ErrorCodeProducerNotRegistered},
+			"subscribe with bad body": {SubscribeServiceNotifications, "---",
+				"ns:app", http.StatusBadRequest, ErrorCodeInvalidRequest},
+			"unsubscribe with bad body": {UnsubscribeNamespaceNotifications,
+				"---", "ns:app", http.StatusBadRequest,
+				ErrorCodeInvalidRequest},
+		} {
+			req := httptest.NewRequest("POST", "/", strings.NewReader(tc.body))
+			ctx := context.WithValue(req.Context(),
+				contextKey("appliance-ctx"), eaaCtx)
+			ctx = context.WithValue(ctx, clientCommonNameKey, tc.commonName)
+			rec := httptest.NewRecorder()
+			tc.handler(rec, req.WithContext(ctx))
+
+			Expect(rec.Code).To(Equal(tc.status), name)
+			var resp ErrorResponse
+			Expect(json.NewDecoder(rec.Body).Decode(&resp)).To(Succeed(), name)
+			Expect(resp.Code).To(Equal(tc.code), name)
+		}
+	})
+})
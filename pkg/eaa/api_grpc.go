@@ -0,0 +1,643 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package eaa
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"net"
+	"sync"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/open-ness/edgenode/pkg/eaa/pb"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// grpcServer implements the gRPC API of EAA
+type grpcServer struct {
+	eaaCtx *Context
+}
+
+// runGrpcServer starts serving the gRPC API of EAA on GrpcEndpoint, the
+// returned server has to be stopped by the caller
+func runGrpcServer(eaaCtx *Context,
+	certPool *x509.CertPool) (*grpc.Server, error) {
+
+	creds := credentials.NewTLS(&tls.Config{
+		ClientAuth:            eaaCtx.tokenAuth.clientAuth(),
+		GetCertificate:        eaaCtx.serverCert.getCertificate,
+		ClientCAs:             certPool,
+		MinVersion:            tls.VersionTLS12,
+		CipherSuites:          []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256},
+		VerifyPeerCertificate: eaaCtx.revocation.verifyPeerCertificate,
+	})
+
+	lis, err := net.Listen("tcp", eaaCtx.cfg.GrpcEndpoint)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to listen on %s",
+			eaaCtx.cfg.GrpcEndpoint)
+	}
+	eaaCtx.grpcAddr = lis.Addr().String()
+
+	opts := []grpc.ServerOption{
+		grpc.Creds(creds),
+		grpc.ChainUnaryInterceptor(unaryTokenAuthenticator(eaaCtx),
+			unaryAuditor(eaaCtx), unaryRateLimiter(eaaCtx),
+			unaryStaleRefresher(eaaCtx)),
+		grpc.ChainStreamInterceptor(streamTokenAuthenticator(eaaCtx),
+			streamRateLimiter(eaaCtx), streamStaleRefresher(eaaCtx)),
+	}
+	if ping := eaaCtx.cfg.Keepalive.PingInterval.Duration; ping > 0 {
+		params := keepalive.ServerParameters{Time: ping}
+		if idle := eaaCtx.cfg.Keepalive.IdleTimeout.Duration; idle > 0 {
+			params.Timeout = idle - ping
+		}
+		opts = append(opts, grpc.KeepaliveParams(params))
+	}
+
+	server := grpc.NewServer(opts...)
+	pb.RegisterEAAServer(server, &grpcServer{eaaCtx: eaaCtx})
+
+	go func() {
+		log.Infof("Serving EAA gRPC API on: %s", eaaCtx.grpcAddr)
+		if err := server.Serve(lis); err != nil {
+			log.Errf("EAA gRPC server error: %v", err)
+		}
+	}()
+
+	return server, nil
+}
+
+// unaryRateLimiter returns an interceptor that enforces request limits of
+// the calling client, see clientLimiter
+func unaryRateLimiter(eaaCtx *Context) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{},
+		_ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		commonName, err := commonNameFromContext(ctx)
+		if err != nil {
+			return handler(ctx, req)
+		}
+		if !eaaCtx.limiter.startRequest(commonName) {
+			return nil, status.Error(codes.ResourceExhausted,
+				"request limit exceeded")
+		}
+		defer eaaCtx.limiter.finishRequest(commonName)
+		return handler(ctx, req)
+	}
+}
+
+// streamRateLimiter is the streaming counterpart of unaryRateLimiter
+func streamRateLimiter(eaaCtx *Context) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream,
+		_ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		commonName, err := commonNameFromContext(ss.Context())
+		if err != nil {
+			return handler(srv, ss)
+		}
+		if !eaaCtx.limiter.startRequest(commonName) {
+			return status.Error(codes.ResourceExhausted,
+				"request limit exceeded")
+		}
+		defer eaaCtx.limiter.finishRequest(commonName)
+		return handler(srv, ss)
+	}
+}
+
+// unaryStaleRefresher returns an interceptor that refreshes a stale service
+// of the calling producer, see refreshStaleService
+func unaryStaleRefresher(eaaCtx *Context) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{},
+		_ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if commonName, err := commonNameFromContext(ctx); err == nil {
+			refreshStaleService(commonName, eaaCtx)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// streamStaleRefresher is the streaming counterpart of unaryStaleRefresher
+func streamStaleRefresher(eaaCtx *Context) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream,
+		_ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if commonName, err := commonNameFromContext(ss.Context()); err == nil {
+			refreshStaleService(commonName, eaaCtx)
+		}
+		return handler(srv, ss)
+	}
+}
+
+// commonNameFromContext returns the Common Name of the client certificate
+// of the gRPC call or the identity from its bearer token
+func commonNameFromContext(ctx context.Context) (string, error) {
+	if commonName, ok := ctx.Value(clientCommonNameKey).(string); ok {
+		return commonName, nil
+	}
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", status.Error(codes.Unauthenticated, "no peer information")
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.PeerCertificates) == 0 {
+		return "", status.Error(codes.Unauthenticated,
+			"no client certificate")
+	}
+	return tlsInfo.State.PeerCertificates[0].Subject.CommonName, nil
+}
+
+// RegisterApplication implements gRPC API
+func (s *grpcServer) RegisterApplication(ctx context.Context,
+	in *pb.Service) (*empty.Empty, error) {
+
+	commonName, err := commonNameFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	URN, err := CommonNameStringToURN(commonName)
+	if err != nil {
+		log.Errf("Error during URN generation: %s", err.Error())
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+
+	if !s.eaaCtx.cfg.AccessControl.allowed(commonName, accessActionRegister,
+		commonName) {
+		return nil, status.Error(codes.PermissionDenied,
+			"registration is not allowed")
+	}
+
+	if len(in.GetInfo()) != 0 && !json.Valid(in.GetInfo()) {
+		return nil, status.Error(codes.InvalidArgument, "info is not valid JSON")
+	}
+	serv := serviceFromPb(in)
+	serv.URN = &URN
+	if err = checkNotificationSchemas(&serv); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	err = publishServiceMessage(commonName, &serv, serviceActionRegister,
+		s.eaaCtx)
+	if err != nil {
+		log.Errf("Register Application: %s", err.Error())
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	log.Debugf("Successfully processed gRPC RegisterApplication from %s",
+		commonName)
+	return &empty.Empty{}, nil
+}
+
+// DeregisterApplication implements gRPC API
+func (s *grpcServer) DeregisterApplication(ctx context.Context,
+	_ *empty.Empty) (*empty.Empty, error) {
+
+	commonName, err := commonNameFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	URN, err := CommonNameStringToURN(commonName)
+	if err != nil {
+		log.Errf("Error during converting Common Name to URN: %s",
+			err.Error())
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+
+	s.eaaCtx.serviceInfo.RLock()
+	found := isServicePresent(commonName, s.eaaCtx)
+	s.eaaCtx.serviceInfo.RUnlock()
+	if !found {
+		return nil, status.Error(codes.NotFound, "service is not registered")
+	}
+
+	err = publishServiceMessage(commonName, &Service{URN: &URN},
+		serviceActionDeregister, s.eaaCtx)
+	if err != nil {
+		log.Errf("Deregister Application: %s", err.Error())
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	log.Debugf("Successfully processed gRPC DeregisterApplication from %s",
+		commonName)
+	return &empty.Empty{}, nil
+}
+
+// GetServices implements gRPC API
+func (s *grpcServer) GetServices(ctx context.Context,
+	in *pb.ServicesRequest) (*pb.ServiceList, error) {
+
+	commonName, err := commonNameFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if in.GetLimit() < 0 {
+		return nil, status.Error(codes.InvalidArgument,
+			"limit must not be negative")
+	}
+	query := serviceQuery{
+		namespace: in.GetNamespace(),
+		urnPrefix: in.GetUrnPrefix(),
+		tags:      in.GetTags(),
+		limit:     int(in.GetLimit()),
+		pageToken: in.GetPageToken(),
+	}
+
+	s.eaaCtx.serviceInfo.RLock()
+	defer s.eaaCtx.serviceInfo.RUnlock()
+
+	if s.eaaCtx.serviceInfo.m == nil {
+		return nil, status.Error(codes.Internal, "EAA context is not initialized")
+	}
+
+	servList := queryServices(commonName, query, s.eaaCtx)
+	list := &pb.ServiceList{NextPageToken: servList.NextPageToken}
+	for _, serv := range servList.Services {
+		list.Services = append(list.Services, serviceToPb(serv))
+	}
+
+	log.Debugf("Successfully processed gRPC GetServices from %s", commonName)
+	return list, nil
+}
+
+// GetDeliveryStatistics implements gRPC API
+func (s *grpcServer) GetDeliveryStatistics(ctx context.Context,
+	_ *empty.Empty) (*pb.DeliveryStatistics, error) {
+
+	commonName, err := commonNameFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	URN, err := CommonNameStringToURN(commonName)
+	if err != nil {
+		log.Errf("Error during URN generation: %s", err.Error())
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+
+	stats := s.eaaCtx.deliveryStats.get(URN.String())
+	log.Debugf("Successfully processed gRPC GetDeliveryStatistics from %s",
+		commonName)
+	return &pb.DeliveryStatistics{
+		Sent:         stats.Sent,
+		Acknowledged: stats.Acknowledged,
+		Retried:      stats.Retried,
+		Failed:       stats.Failed,
+	}, nil
+}
+
+// GetSubscriptions implements gRPC API
+func (s *grpcServer) GetSubscriptions(ctx context.Context,
+	_ *empty.Empty) (*pb.SubscriptionList, error) {
+
+	commonName, err := commonNameFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	subs, err := getConsumerSubscriptions(commonName, s.eaaCtx)
+	if err != nil {
+		log.Errf("Consumer Subscription List Getter: %s", err.Error())
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	list := &pb.SubscriptionList{}
+	for _, sub := range subs.Subscriptions {
+		list.Subscriptions = append(list.Subscriptions, &pb.Subscription{
+			Urn:           urnToPb(sub.URN),
+			Notifications: notificationDescriptorsToPb(sub.Notifications),
+		})
+	}
+
+	log.Debugf("Successfully processed gRPC GetSubscriptions from %s",
+		commonName)
+	return list, nil
+}
+
+// PushNotification implements gRPC API
+func (s *grpcServer) PushNotification(ctx context.Context,
+	in *pb.NotificationFromProducer) (*empty.Empty, error) {
+
+	commonName, err := commonNameFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	URN, err := CommonNameStringToURN(commonName)
+	if err != nil {
+		log.Errf("Error during URN generation: %s", err.Error())
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+
+	if len(in.GetPayload()) != 0 && !json.Valid(in.GetPayload()) {
+		return nil, status.Error(codes.InvalidArgument,
+			"payload is not valid JSON")
+	}
+
+	if !s.eaaCtx.limiter.allowNotification(commonName) {
+		return nil, status.Error(codes.ResourceExhausted,
+			"notification rate limit exceeded")
+	}
+
+	s.eaaCtx.serviceInfo.RLock()
+	serv, found := s.eaaCtx.serviceInfo.m[commonName]
+	s.eaaCtx.serviceInfo.RUnlock()
+	if !found {
+		log.Err("Producer is not registered")
+		return nil, status.Error(codes.FailedPrecondition,
+			"producer is not registered")
+	}
+
+	notif := NotificationFromProducer{
+		Name:    in.GetName(),
+		Version: in.GetVersion(),
+		Payload: rawJSON(in.GetPayload()),
+	}
+	if err = checkPayloadSize(&notif, s.eaaCtx); err != nil {
+		log.Errf("Invalid notification from %s: %s", commonName, err.Error())
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err = validateNotificationPayload(&serv, &notif); err != nil {
+		log.Errf("Invalid notification from %s: %s", commonName, err.Error())
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	err = publishNotification(commonName, &URN, &notif, nil, s.eaaCtx)
+	if err != nil {
+		log.Errf("Error in Publish Notification: %s", err.Error())
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	log.Debugf("Successfully processed gRPC PushNotification from %s",
+		commonName)
+	return &empty.Empty{}, nil
+}
+
+// Subscribe implements gRPC API
+func (s *grpcServer) Subscribe(ctx context.Context,
+	in *pb.Subscription) (*empty.Empty, error) {
+
+	commonName, err := commonNameFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if in.GetUrn().GetNamespace() == "" {
+		return nil, status.Error(codes.InvalidArgument,
+			"urn.namespace is required")
+	}
+
+	scope := subscriptionScopeNamespace
+	target := in.GetUrn().GetNamespace() + ":*"
+	if in.GetUrn().GetId() != "" {
+		scope = subscriptionScopeService
+		target = in.GetUrn().GetNamespace() + ":" + in.GetUrn().GetId()
+	}
+	urn := URN{ID: in.GetUrn().GetId(), Namespace: in.GetUrn().GetNamespace()}
+	notifs := notificationDescriptorsFromPb(in.GetNotifications())
+
+	if err = checkSubscriptionPatterns(&urn, notifs); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if !s.eaaCtx.cfg.AccessControl.allowed(commonName, accessActionSubscribe,
+		target) {
+		return nil, status.Error(codes.PermissionDenied,
+			"subscription is not allowed")
+	}
+
+	err = processSubscriptionRequest(subscriptionActionSubscribe, scope,
+		commonName, &urn, notifs, nil, s.eaaCtx)
+	if err != nil {
+		log.Errf("Error during Subscription Request processing: %s",
+			err.Error())
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	log.Debugf("Successfully processed gRPC Subscribe from %s", commonName)
+	return &empty.Empty{}, nil
+}
+
+// Unsubscribe implements gRPC API
+func (s *grpcServer) Unsubscribe(ctx context.Context,
+	in *pb.Subscription) (*empty.Empty, error) {
+
+	commonName, err := commonNameFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		scope = subscriptionScopeAll
+		urn   *URN
+		subs  []NotificationDescriptor
+	)
+	if in.GetUrn() != nil {
+		if in.GetUrn().GetNamespace() == "" {
+			return nil, status.Error(codes.InvalidArgument,
+				"urn.namespace is required")
+		}
+
+		scope = subscriptionScopeNamespace
+		if in.GetUrn().GetId() != "" {
+			scope = subscriptionScopeService
+		}
+		urn = &URN{ID: in.GetUrn().GetId(),
+			Namespace: in.GetUrn().GetNamespace()}
+		subs = notificationDescriptorsFromPb(in.GetNotifications())
+	}
+
+	err = processSubscriptionRequest(subscriptionActionUnsubscribe, scope,
+		commonName, urn, subs, nil, s.eaaCtx)
+	if err != nil {
+		log.Errf("Error during Unsubscription Request processing: %s",
+			err.Error())
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	log.Debugf("Successfully processed gRPC Unsubscribe from %s", commonName)
+	return &empty.Empty{}, nil
+}
+
+// GetNotifications implements gRPC API. The stream replaces any previous
+// notifications connection of the consumer and lasts until the consumer
+// or EAA ends it.
+func (s *grpcServer) GetNotifications(in *pb.NotificationsRequest,
+	stream pb.EAA_GetNotificationsServer) error {
+
+	commonName, err := commonNameFromContext(stream.Context())
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	// The stream ends with the error passed to closeStream first
+	var (
+		closeOnce sync.Once
+		closeErr  error
+	)
+	conn := ConsumerConnection{
+		notifications: make(chan []byte, sendQueueSize(s.eaaCtx)),
+		closeStream: func(reason error) {
+			closeOnce.Do(func() {
+				closeErr = reason
+				cancel()
+			})
+		},
+	}
+
+	// Take the notifications to replay together with registering the
+	// connection, so that each notification is either replayed or queued
+	var replay [][]byte
+	s.eaaCtx.consumerConnections.Lock()
+	if prevConn, found := s.eaaCtx.consumerConnections.m[commonName]; found {
+		closeConsumerConnection(prevConn)
+	}
+	if in.GetReplay() {
+		replay = s.eaaCtx.notificationBuffer.since(commonName, in.GetSince())
+	}
+	s.eaaCtx.consumerConnections.m[commonName] = conn
+	s.eaaCtx.consumerConnections.Unlock()
+
+	defer func() {
+		s.eaaCtx.consumerConnections.Lock()
+		defer s.eaaCtx.consumerConnections.Unlock()
+
+		// Keep the entry if it was already replaced by a new connection
+		if c, found := s.eaaCtx.consumerConnections.m[commonName]; found &&
+			c.notifications == conn.notifications {
+			delete(s.eaaCtx.consumerConnections.m, commonName)
+		}
+	}()
+
+	// Subscribe to the Client topic to receive all of its subscriptions
+	topic := getClientTopicName(commonName)
+	err = s.eaaCtx.MsgBrokerCtx.addSubscriber(clientSubscriber, topic, nil)
+	if err != nil {
+		// Ignore objectAlreadyExistsError error
+		if _, ok := err.(objectAlreadyExistsError); !ok {
+			log.Errf("Error when adding a Subscriber of type: '%v', topic: '%v'",
+				clientSubscriber, topic)
+			return status.Error(codes.Internal, err.Error())
+		}
+	}
+
+	log.Debugf("Successfully processed gRPC GetNotifications from %s",
+		commonName)
+
+	for _, payload := range replay {
+		if err = sendStreamNotification(stream, payload); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			if stream.Context().Err() != nil {
+				return nil
+			}
+			return closeErr
+		case payload := <-conn.notifications:
+			if err = sendStreamNotification(stream, payload); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// sendStreamNotification sends the JSON encoded NotificationToConsumer over
+// the gRPC stream, notifications that can't be decoded are skipped
+func sendStreamNotification(stream pb.EAA_GetNotificationsServer,
+	payload []byte) error {
+
+	var notif NotificationToConsumer
+	if err := json.Unmarshal(payload, &notif); err != nil {
+		log.Errf("Failed to unmarshal notification: %v", err)
+		return nil
+	}
+	return stream.Send(&pb.NotificationToConsumer{
+		Name:     notif.Name,
+		Version:  notif.Version,
+		Payload:  notif.Payload,
+		Producer: urnToPb(&notif.URN),
+		Sequence: notif.Sequence,
+	})
+}
+
+func rawJSON(b []byte) json.RawMessage {
+	if len(b) == 0 {
+		return nil
+	}
+	return json.RawMessage(b)
+}
+
+func urnToPb(urn *URN) *pb.URN {
+	if urn == nil {
+		return nil
+	}
+	return &pb.URN{Id: urn.ID, Namespace: urn.Namespace}
+}
+
+func notificationDescriptorsToPb(
+	notifs []NotificationDescriptor) []*pb.NotificationDescriptor {
+
+	var res []*pb.NotificationDescriptor
+	for _, n := range notifs {
+		res = append(res, &pb.NotificationDescriptor{
+			Name:        n.Name,
+			Version:     n.Version,
+			Description: n.Description,
+			Schema:      n.Schema,
+		})
+	}
+	return res
+}
+
+func notificationDescriptorsFromPb(
+	notifs []*pb.NotificationDescriptor) []NotificationDescriptor {
+
+	var res []NotificationDescriptor
+	for _, n := range notifs {
+		res = append(res, NotificationDescriptor{
+			Name:        n.GetName(),
+			Version:     n.GetVersion(),
+			Description: n.GetDescription(),
+			Schema:      rawJSON(n.GetSchema()),
+		})
+	}
+	return res
+}
+
+func serviceToPb(serv Service) *pb.Service {
+	return &pb.Service{
+		Urn:           urnToPb(serv.URN),
+		Description:   serv.Description,
+		EndpointUri:   serv.EndpointURI,
+		Status:        serv.Status,
+		Notifications: notificationDescriptorsToPb(serv.Notifications),
+		Info:          serv.Info,
+		Tags:          serv.Tags,
+		Node:          serv.Node,
+	}
+}
+
+func serviceFromPb(in *pb.Service) Service {
+	return Service{
+		Description:   in.GetDescription(),
+		EndpointURI:   in.GetEndpointUri(),
+		Status:        in.GetStatus(),
+		Notifications: notificationDescriptorsFromPb(in.GetNotifications()),
+		Info:          rawJSON(in.GetInfo()),
+		Tags:          in.GetTags(),
+	}
+}
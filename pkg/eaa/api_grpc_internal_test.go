@@ -0,0 +1,70 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package eaa
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/golang/protobuf/ptypes/empty"
+	g "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// publishRecorder is a msgBroker that only records published messages
+type publishRecorder struct {
+	published []*message.Message
+}
+
+func (b *publishRecorder) addPublisher(publisherType, string, *http.Request) error {
+	return nil
+}
+
+func (b *publishRecorder) publish(_ string, msg *message.Message) error {
+	b.published = append(b.published, msg)
+	return nil
+}
+
+func (b *publishRecorder) addSubscriber(subscriberType, string, *http.Request) error {
+	return nil
+}
+
+func (b *publishRecorder) removeAll() error {
+	return nil
+}
+
+var _ = g.Describe("gRPC DeregisterApplication", func() {
+	var (
+		broker *publishRecorder
+		server *grpcServer
+		ctx    context.Context
+	)
+
+	g.BeforeEach(func() {
+		broker = &publishRecorder{}
+		eaaCtx := &Context{MsgBrokerCtx: broker}
+		eaaCtx.serviceInfo.m = make(map[string]Service)
+		server = &grpcServer{eaaCtx: eaaCtx}
+		ctx = context.WithValue(context.Background(), clientCommonNameKey,
+			"namespace-1:producer-1")
+	})
+
+	g.It("does not publish for a service that is not registered", func() {
+		_, err := server.DeregisterApplication(ctx, &empty.Empty{})
+		Expect(status.Code(err)).To(Equal(codes.NotFound))
+		Expect(broker.published).To(BeEmpty())
+	})
+
+	g.It("publishes the deregistration of a registered service", func() {
+		server.eaaCtx.serviceInfo.m["namespace-1:producer-1"] = Service{}
+
+		_, err := server.DeregisterApplication(ctx, &empty.Empty{})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(broker.published).To(HaveLen(1))
+		Expect(broker.published[0].UUID).To(Equal("namespace-1:producer-1"))
+	})
+})
@@ -0,0 +1,223 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package eaa_test
+
+import (
+	"context"
+	"crypto/tls"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+
+	"github.com/open-ness/edgenode/pkg/eaa/pb"
+)
+
+func createGrpcClient(commonName string) (pb.EAAClient, *grpc.ClientConn) {
+	certTempl := GetCertTempl()
+	certTempl.Subject.CommonName = commonName
+	cert, certPool := generateSignedClientCert(&certTempl)
+
+	creds := credentials.NewTLS(&tls.Config{
+		RootCAs:      certPool,
+		Certificates: []tls.Certificate{cert},
+		ServerName:   EaaCommonName,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, cfg.GrpcEndpoint,
+		grpc.WithTransportCredentials(creds), grpc.WithBlock())
+	Expect(err).ShouldNot(HaveOccurred())
+
+	return pb.NewEAAClient(conn), conn
+}
+
+var _ = Describe("gRPC API", func() {
+	startStopCh := make(chan bool)
+
+	var (
+		ctx      context.Context
+		cancel   context.CancelFunc
+		prod     pb.EAAClient
+		prodConn *grpc.ClientConn
+		cons     pb.EAAClient
+		consConn *grpc.ClientConn
+	)
+
+	BeforeEach(func() {
+		Expect(runEaa(startStopCh)).ShouldNot(HaveOccurred())
+
+		ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+		prod, prodConn = createGrpcClient(Name1Prod1)
+		cons, consConn = createGrpcClient(Name1Cons1)
+	})
+
+	AfterEach(func() {
+		cancel()
+		prodConn.Close()
+		consConn.Close()
+		stopEaa(startStopCh)
+	})
+
+	registerService := func() {
+		_, err := prod.RegisterApplication(ctx, &pb.Service{
+			Description: "The Sanctuary",
+			EndpointUri: "https://1.2.3.4",
+			Notifications: []*pb.NotificationDescriptor{
+				{Name: "Event #1", Version: "1.0.0"},
+			},
+			Info: []byte(`{"key":"value"}`),
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		Eventually(func() int {
+			list, err := cons.GetServices(ctx, &pb.ServicesRequest{})
+			Expect(err).ShouldNot(HaveOccurred())
+			return len(list.Services)
+		}, 2*time.Second, 50*time.Millisecond).Should(Equal(1))
+	}
+
+	It("Registers and lists services", func() {
+		registerService()
+
+		list, err := cons.GetServices(ctx, &pb.ServicesRequest{})
+		Expect(err).ShouldNot(HaveOccurred())
+		serv := list.Services[0]
+		Expect(serv.Urn.Id).To(Equal("producer-1"))
+		Expect(serv.Urn.Namespace).To(Equal("namespace-1"))
+		Expect(serv.EndpointUri).To(Equal("https://1.2.3.4"))
+		Expect(serv.Notifications).To(HaveLen(1))
+		Expect(serv.Info).To(MatchJSON(`{"key":"value"}`))
+
+		_, err = prod.DeregisterApplication(ctx, &empty.Empty{})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		Eventually(func() int {
+			list, err := cons.GetServices(ctx, &pb.ServicesRequest{})
+			Expect(err).ShouldNot(HaveOccurred())
+			return len(list.Services)
+		}, 2*time.Second, 50*time.Millisecond).Should(BeZero())
+	})
+
+	It("Fails to deregister a service that is not registered", func() {
+		_, err := prod.DeregisterApplication(ctx, &empty.Empty{})
+		Expect(status.Code(err)).To(Equal(codes.NotFound))
+	})
+
+	It("Fails to push a notification of an unregistered producer", func() {
+		_, err := prod.PushNotification(ctx, &pb.NotificationFromProducer{
+			Name: "Event #1", Version: "1.0.0"})
+		Expect(status.Code(err)).To(Equal(codes.FailedPrecondition))
+	})
+
+	It("Streams notifications to the subscribed consumer", func() {
+		registerService()
+
+		sub := &pb.Subscription{
+			Urn: &pb.URN{Namespace: "namespace-1"},
+			Notifications: []*pb.NotificationDescriptor{
+				{Name: "Event #1", Version: "1.0.0"},
+			},
+		}
+		_, err := cons.Subscribe(ctx, sub)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		Eventually(func() int {
+			list, err := cons.GetSubscriptions(ctx, &empty.Empty{})
+			Expect(err).ShouldNot(HaveOccurred())
+			return len(list.Subscriptions)
+		}, 2*time.Second, 50*time.Millisecond).Should(Equal(1))
+
+		stream, err := cons.GetNotifications(ctx, &pb.NotificationsRequest{})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		received := make(chan *pb.NotificationToConsumer, 1)
+		go func() {
+			defer GinkgoRecover()
+			notif, err := stream.Recv()
+			if err == nil {
+				received <- notif
+			}
+		}()
+
+		// The stream is registered asynchronously, so keep pushing until
+		// the first notification arrives
+		var notif *pb.NotificationToConsumer
+		Eventually(func() bool {
+			_, err := prod.PushNotification(ctx, &pb.NotificationFromProducer{
+				Name:    "Event #1",
+				Version: "1.0.0",
+				Payload: []byte(`{"msg":"hello"}`),
+			})
+			Expect(err).ShouldNot(HaveOccurred())
+			select {
+			case notif = <-received:
+				return true
+			case <-time.After(100 * time.Millisecond):
+				return false
+			}
+		}, 3*time.Second).Should(BeTrue())
+
+		Expect(notif.Name).To(Equal("Event #1"))
+		Expect(notif.Payload).To(MatchJSON(`{"msg":"hello"}`))
+		Expect(notif.Producer.Id).To(Equal("producer-1"))
+		Expect(notif.Producer.Namespace).To(Equal("namespace-1"))
+
+		_, err = cons.Unsubscribe(ctx, &pb.Subscription{})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		Eventually(func() int {
+			list, err := cons.GetSubscriptions(ctx, &empty.Empty{})
+			Expect(err).ShouldNot(HaveOccurred())
+			return len(list.Subscriptions)
+		}, 2*time.Second, 50*time.Millisecond).Should(BeZero())
+	})
+
+	It("Validates notification payloads against registered schemas", func() {
+		_, err := prod.RegisterApplication(ctx, &pb.Service{
+			Notifications: []*pb.NotificationDescriptor{
+				{Name: "Event #1", Version: "1.0.0", Schema: []byte(`{"type":1}`)},
+			},
+		})
+		Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+
+		_, err = prod.RegisterApplication(ctx, &pb.Service{
+			Notifications: []*pb.NotificationDescriptor{
+				{Name: "Event #1", Version: "1.0.0", Schema: []byte(
+					`{"type":"object","required":["msg"]}`)},
+			},
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		// Until the registration is handled, pushing fails as the producer
+		// is not registered yet
+		Eventually(func() codes.Code {
+			_, err := prod.PushNotification(ctx, &pb.NotificationFromProducer{
+				Name:    "Event #1",
+				Version: "1.0.0",
+				Payload: []byte(`{"other":"hello"}`),
+			})
+			return status.Code(err)
+		}, 2*time.Second, 50*time.Millisecond).Should(
+			Equal(codes.InvalidArgument))
+
+		_, err = prod.PushNotification(ctx, &pb.NotificationFromProducer{
+			Name:    "Event #1",
+			Version: "1.0.0",
+			Payload: []byte(`{"msg":"hello"}`),
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	It("Rejects a subscription without a namespace", func() {
+		_, err := cons.Subscribe(ctx, &pb.Subscription{})
+		Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+	})
+})
@@ -6,7 +6,6 @@ package eaa
 import (
 	"encoding/json"
 	"net/http"
-	"strings"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -73,30 +72,9 @@ func removeService(commonName string, eaaCtx *Context) error {
 	return errors.New(http.StatusText(http.StatusNotFound))
 }
 
-func getUniqueSubsList(nsList []string, servList []string) []string {
-	fullList := nsList
-
-	for _, subID := range servList {
-		isNamespaceSubscribed := false
-		for _, nsSubID := range nsList {
-			if res := strings.Compare(subID, nsSubID); res == 0 {
-				isNamespaceSubscribed = true
-				break
-			}
-		}
-		if !isNamespaceSubscribed {
-			fullList = append(fullList, subID)
-		}
-	}
-
-	return fullList
-}
-
 func sendNotificationToAllSubscribers(commonName string, notif *NotificationFromProducer,
 	eaaCtx *Context) error {
 
-	var subscriberList []string
-
 	eaaCtx.serviceInfo.RLock()
 	defer eaaCtx.serviceInfo.RUnlock()
 
@@ -109,47 +87,47 @@ func sendNotificationToAllSubscribers(commonName string, notif *NotificationFrom
 		return err
 	}
 
-	msgPayload, err := json.Marshal(NotificationToConsumer{
-		Name:    notif.Name,
-		Version: notif.Version,
-		Payload: notif.Payload,
-		URN:     prodURN,
-	})
-	if err != nil {
-		return errors.Wrap(err, "Failed to marshal norification JSON")
-	}
-
 	_, serviceFound := eaaCtx.serviceInfo.m[commonName]
 	if !serviceFound {
 		return errors.New("Producer is not registered")
 	}
 
-	namespaceKey := UniqueNotif{
-		namespace:    prodURN.Namespace,
-		notifName:    notif.Name,
-		notifVersion: notif.Version,
+	return deliverNotification(prodURN, notif, true, eaaCtx)
+}
+
+// deliverNotification sends the notification of the producer to its
+// subscribers. Federation peers subscribed to the producer are skipped
+// unless toPeers is set.
+func deliverNotification(prodURN URN, notif *NotificationFromProducer,
+	toPeers bool, eaaCtx *Context) error {
+
+	seq := eaaCtx.notificationBuffer.nextSequence()
+	msgPayload, err := json.Marshal(NotificationToConsumer{
+		Name:     notif.Name,
+		Version:  notif.Version,
+		Payload:  notif.Payload,
+		URN:      prodURN,
+		Sequence: seq,
+	})
+	if err != nil {
+		return errors.Wrap(err, "Failed to marshal norification JSON")
 	}
 
 	eaaCtx.subscriptionInfo.RLock()
 	defer eaaCtx.subscriptionInfo.RUnlock()
 
-	namespaceSubsInfo, ok := eaaCtx.subscriptionInfo.m[namespaceKey]
-	if !ok {
-		log.Infof("No subscription to notification %v", namespaceKey)
+	subscriberList := subscribersOf(prodURN, notif, eaaCtx)
+	if len(subscriberList) == 0 {
+		log.Infof("No subscription to notification %s %s of %s", notif.Name,
+			notif.Version, prodURN.String())
 		return nil
 	}
 
-	srvSubsList, ok := namespaceSubsInfo.serviceSubscriptions[prodURN.ID]
-
-	if !ok {
-		subscriberList = namespaceSubsInfo.namespaceSubscriptions
-	} else {
-		subscriberList = getUniqueSubsList(
-			namespaceSubsInfo.namespaceSubscriptions, srvSubsList)
-	}
-
 	for _, subID := range subscriberList {
-		if err = sendNotificationToSubscriber(subID, msgPayload,
+		if !toPeers && isFederationPeer(subID) {
+			continue
+		}
+		if err = sendNotificationToSubscriber(subID, seq, msgPayload,
 			eaaCtx); err != nil {
 			log.Warningf("Couldn't send notification to Subscriber ID: %s : %v",
 				subID, err)
@@ -158,15 +136,24 @@ func sendNotificationToAllSubscribers(commonName string, notif *NotificationFrom
 	return nil
 }
 
-func sendNotificationToSubscriber(subID string, msgPayload []byte,
-	eaaCtx *Context) error {
+func sendNotificationToSubscriber(subID string, seq uint64,
+	msgPayload []byte, eaaCtx *Context) error {
 
 	eaaCtx.consumerConnections.RLock()
 
+	// Store the notification for replay while holding the connections lock
+	// so a reconnecting consumer gets it either replayed or sent, not both
+	eaaCtx.notificationBuffer.add(subID, seq, msgPayload)
+
 	possibleConnection, connectionFound := eaaCtx.consumerConnections.m[subID]
 	log.Infof("Looking for websocket: %s from %v", subID,
 		eaaCtx.consumerConnections.m)
 	if connectionFound {
+		if possibleConnection.notifications != nil {
+			eaaCtx.consumerConnections.RUnlock()
+			return queueStreamNotification(possibleConnection, subID,
+				msgPayload, eaaCtx)
+		}
 		if possibleConnection.connection == nil {
 			// Unlock consumer connections to allow the other thread to update it
 			eaaCtx.consumerConnections.RUnlock()
@@ -178,7 +165,15 @@ func sendNotificationToSubscriber(subID string, msgPayload []byte,
 		}
 		messageType := websocket.TextMessage
 		conn := eaaCtx.consumerConnections.m[subID].connection
-		err := conn.WriteMessage(messageType, msgPayload)
+		var err error
+		if queue := eaaCtx.consumerConnections.m[subID].queue; queue != nil {
+			err = queueWsNotification(eaaCtx.consumerConnections.m[subID],
+				subID, seq, msgPayload, eaaCtx)
+		} else if acks := eaaCtx.consumerConnections.m[subID].acks; acks != nil {
+			err = acks.send(conn, seq, msgPayload, eaaCtx)
+		} else {
+			err = conn.WriteMessage(messageType, msgPayload)
+		}
 		eaaCtx.consumerConnections.RUnlock()
 		return err
 	}
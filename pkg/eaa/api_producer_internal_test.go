@@ -33,7 +33,7 @@ var _ = g.Describe("api_procuder internal errors", func() {
 
 		eaaContext.consumerConnections = consumerConns{m: make(map[string]ConsumerConnection)}
 
-		cc := ConsumerConnection{&websocket.Conn{}}
+		cc := ConsumerConnection{connection: &websocket.Conn{}}
 		eaaContext.consumerConnections.m["aa"] = cc
 		eaaContext.consumerConnections.m["bb"] = cc
 		eaaContext.consumerConnections.m["cc"] = cc
@@ -97,7 +97,8 @@ var _ = g.Describe("api_procuder internal errors", func() {
 				cs := &ConsumerSubscription{
 					namespaceSubscriptions: SubscriberIds{"aa", "bb"},
 					serviceSubscriptions:   make(map[string]SubscriberIds),
-					notification:           NotificationDescriptor{"name", "1.0", "description"},
+					notification: NotificationDescriptor{Name: "name", Version: "1.0",
+						Description: "description"},
 				}
 
 				cs.serviceSubscriptions[urn.ID] = SubscriberIds{"bb", "cc"}
@@ -217,11 +218,11 @@ var _ = g.Describe("api_procuder internal errors", func() {
 							time.Sleep(500 * time.Millisecond)
 
 							eaaContext.consumerConnections.RLock()
-							eaaContext.consumerConnections.m[subscriptionID] = ConsumerConnection{&websocket.Conn{}}
+							eaaContext.consumerConnections.m[subscriptionID] = ConsumerConnection{connection: &websocket.Conn{}}
 							eaaContext.consumerConnections.RUnlock()
 						}()
 
-						e = sendNotificationToSubscriber(subscriptionID, []byte{1, 2, 3}, eaaContext)
+						e = sendNotificationToSubscriber(subscriptionID, 0, []byte{1, 2, 3}, eaaContext)
 
 						Expect(e).NotTo(HaveOccurred())
 						Expect(calls).To(Equal(1))
@@ -230,7 +231,7 @@ var _ = g.Describe("api_procuder internal errors", func() {
 
 			g.When("and websocket is not created in time", func() {
 				g.It("should fail with an error", func() {
-					e := sendNotificationToSubscriber(subscriptionID, []byte{1, 2, 3}, eaaContext)
+					e := sendNotificationToSubscriber(subscriptionID, 0, []byte{1, 2, 3}, eaaContext)
 
 					Expect(e).To(HaveOccurred())
 				})
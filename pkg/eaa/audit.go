@@ -0,0 +1,299 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package eaa
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/open-ness/edgenode/pkg/eaa/pb"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Audit verbosity levels, see AuditConfig
+const (
+	auditVerbosityFailures = "failures"
+	auditVerbosityChanges  = "changes"
+	auditVerbosityAll      = "all"
+)
+
+// Audited actions
+const (
+	auditActionRegister    = "register"
+	auditActionDeregister  = "deregister"
+	auditActionSubscribe   = "subscribe"
+	auditActionUnsubscribe = "unsubscribe"
+	auditActionPublish     = "publish"
+)
+
+// auditRoutes maps names of audited routes to their actions
+var auditRoutes = map[string]string{
+	"RegisterApplication":               auditActionRegister,
+	"DeregisterApplication":             auditActionDeregister,
+	"SubscribeNamespaceNotifications":   auditActionSubscribe,
+	"SubscribeServiceNotifications":     auditActionSubscribe,
+	"UnsubscribeAllNotifications":       auditActionUnsubscribe,
+	"UnsubscribeNamespaceNotifications": auditActionUnsubscribe,
+	"UnsubscribeServiceNotifications":   auditActionUnsubscribe,
+	"PushNotificationToSubscribers":     auditActionPublish,
+}
+
+// auditMethods maps full names of audited gRPC methods to their actions
+var auditMethods = map[string]string{
+	"/pb.EAA/RegisterApplication":   auditActionRegister,
+	"/pb.EAA/DeregisterApplication": auditActionDeregister,
+	"/pb.EAA/Subscribe":             auditActionSubscribe,
+	"/pb.EAA/Unsubscribe":           auditActionUnsubscribe,
+	"/pb.EAA/PushNotification":      auditActionPublish,
+}
+
+// AuditRecord is a line of the audit log
+type AuditRecord struct {
+	Time time.Time `json:"time"`
+	// Client is the identity of the client, the peer address of clients
+	// rejected before they were identified
+	Client string `json:"client"`
+	// Action is one of register, deregister, subscribe, unsubscribe and
+	// publish
+	Action string `json:"action"`
+	// Target is "namespace:id" of the service or "namespace:*" of the
+	// namespace acted on, "*" for removal of all subscriptions
+	Target string `json:"target"`
+	// Outcome is "success" or "failure"
+	Outcome string `json:"outcome"`
+	// Status is the HTTP status or gRPC code of the response
+	Status string `json:"status"`
+	// CorrelationID of the REST request, see ErrorResponse
+	CorrelationID string `json:"correlation_id,omitempty"`
+}
+
+// auditLog appends AuditRecords as JSON lines to a file. A nil auditLog
+// records nothing.
+type auditLog struct {
+	sync.Mutex
+	file      *os.File
+	verbosity string
+}
+
+func newAuditLog(cfg AuditConfig) (*auditLog, error) {
+	file, err := os.OpenFile(filepath.Clean(cfg.Path),
+		os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to open audit log")
+	}
+
+	verbosity := cfg.Verbosity
+	if verbosity == "" {
+		verbosity = auditVerbosityChanges
+	}
+	return &auditLog{file: file, verbosity: verbosity}, nil
+}
+
+// record writes the record unless it's filtered out by the verbosity:
+// failures records failed actions only, changes records all registrations
+// and subscriptions and failed publishes, all records everything
+func (a *auditLog) record(rec AuditRecord) {
+	if a == nil {
+		return
+	}
+
+	failed := rec.Outcome != "success"
+	switch a.verbosity {
+	case auditVerbosityFailures:
+		if !failed {
+			return
+		}
+	case auditVerbosityChanges:
+		if !failed && rec.Action == auditActionPublish {
+			return
+		}
+	}
+
+	rec.Time = time.Now().UTC()
+	line, err := json.Marshal(rec)
+	if err != nil {
+		log.Errf("Failed to encode audit record: %v", err)
+		return
+	}
+
+	a.Lock()
+	defer a.Unlock()
+	if _, err = a.file.Write(append(line, '\n')); err != nil {
+		log.Errf("Failed to write audit record: %v", err)
+	}
+}
+
+func (a *auditLog) close() {
+	if a == nil {
+		return
+	}
+
+	a.Lock()
+	defer a.Unlock()
+	if err := a.file.Close(); err != nil {
+		log.Errf("Failed to close audit log: %v", err)
+	}
+}
+
+// auditTarget returns the target of the action of the client on the URN,
+// urn is nil for removal of all subscriptions
+func auditTarget(commonName, action string, urn *URN) string {
+	switch action {
+	case auditActionSubscribe, auditActionUnsubscribe:
+		if urn == nil {
+			return "*"
+		}
+		if urn.ID == "" {
+			return urn.Namespace + ":*"
+		}
+		return urn.String()
+	default:
+		return commonName
+	}
+}
+
+func auditOutcome(ok bool) string {
+	if ok {
+		return "success"
+	}
+	return "failure"
+}
+
+// statusRecorder records the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// Hijack lets websocket connections of consumers upgrade through the
+// recorder
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("Response writer doesn't support hijacking")
+	}
+	if r.status == 0 {
+		r.status = http.StatusSwitchingProtocols
+	}
+	return hijacker.Hijack()
+}
+
+// auditMiddleware records audited requests in the audit log
+func auditMiddleware(eaaCtx *Context) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if eaaCtx.audit == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			rec := &statusRecorder{ResponseWriter: w}
+			next.ServeHTTP(rec, r)
+			if rec.status == 0 {
+				rec.status = http.StatusOK
+			}
+			auditRequest(eaaCtx, r, clientCommonName(r), rec.status)
+		})
+	}
+}
+
+// auditRequest records the request if its route is audited, client is the
+// peer address if empty
+func auditRequest(eaaCtx *Context, r *http.Request, client string,
+	statusCode int) {
+
+	route := mux.CurrentRoute(r)
+	if eaaCtx.audit == nil || route == nil {
+		return
+	}
+	action, audited := auditRoutes[route.GetName()]
+	if !audited {
+		return
+	}
+	if client == "" {
+		client = r.RemoteAddr
+	}
+
+	var urn *URN
+	if vars := mux.Vars(r); vars["urn.namespace"] != "" {
+		urn = &URN{Namespace: vars["urn.namespace"], ID: vars["urn.id"]}
+	}
+	eaaCtx.audit.record(AuditRecord{
+		Client:        client,
+		Action:        action,
+		Target:        auditTarget(client, action, urn),
+		Outcome:       auditOutcome(statusCode < http.StatusBadRequest),
+		Status:        strconv.Itoa(statusCode),
+		CorrelationID: r.Header.Get(correlationIDHeader),
+	})
+}
+
+// unaryAuditor returns an interceptor that records audited gRPC calls in
+// the audit log
+func unaryAuditor(eaaCtx *Context) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{},
+		info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if eaaCtx.audit == nil {
+			return handler(ctx, req)
+		}
+
+		resp, err := handler(ctx, req)
+		client, _ := commonNameFromContext(ctx)
+		auditCall(ctx, eaaCtx, req, info.FullMethod, client, err)
+		return resp, err
+	}
+}
+
+// auditCall records the gRPC call if its method is audited, client is the
+// peer address if empty
+func auditCall(ctx context.Context, eaaCtx *Context, req interface{},
+	method, client string, err error) {
+
+	action, audited := auditMethods[method]
+	if eaaCtx.audit == nil || !audited {
+		return
+	}
+	if p, ok := peer.FromContext(ctx); client == "" && ok && p.Addr != nil {
+		client = p.Addr.String()
+	}
+
+	var urn *URN
+	if sub, ok := req.(*pb.Subscription); ok && sub.GetUrn() != nil {
+		urn = &URN{Namespace: sub.GetUrn().GetNamespace(),
+			ID: sub.GetUrn().GetId()}
+	}
+	eaaCtx.audit.record(AuditRecord{
+		Client:  client,
+		Action:  action,
+		Target:  auditTarget(client, action, urn),
+		Outcome: auditOutcome(err == nil),
+		Status:  status.Code(err).String(),
+	})
+}
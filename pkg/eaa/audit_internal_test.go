@@ -0,0 +1,221 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package eaa
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	g "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/open-ness/edgenode/pkg/eaa/pb"
+)
+
+var _ = g.Describe("auditLog", func() {
+	const client = "ns:app"
+
+	var (
+		dir    string
+		eaaCtx *Context
+	)
+
+	clientTLS := &tls.ConnectionState{PeerCertificates: []*x509.Certificate{
+		{Subject: pkix.Name{CommonName: client}}}}
+
+	records := func() []AuditRecord {
+		f, err := os.Open(filepath.Join(dir, "audit.log"))
+		Expect(err).ShouldNot(HaveOccurred())
+		defer f.Close()
+
+		var recs []AuditRecord
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var rec AuditRecord
+			Expect(json.Unmarshal(scanner.Bytes(), &rec)).To(Succeed())
+			Expect(rec.Time).ToNot(BeZero())
+			rec.Time = time.Time{}
+			recs = append(recs, rec)
+		}
+		return recs
+	}
+
+	open := func(verbosity string) {
+		var err error
+		eaaCtx.audit, err = newAuditLog(AuditConfig{
+			Path:      filepath.Join(dir, "audit.log"),
+			Verbosity: verbosity,
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+	}
+
+	g.BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "eaa-audit")
+		Expect(err).ShouldNot(HaveOccurred())
+		eaaCtx = &Context{}
+	})
+
+	g.AfterEach(func() {
+		eaaCtx.audit.close()
+		os.RemoveAll(dir)
+	})
+
+	g.It("filters records by verbosity", func() {
+		recs := []AuditRecord{
+			{Client: client, Action: auditActionRegister, Target: client,
+				Outcome: "success", Status: "200"},
+			{Client: client, Action: auditActionPublish, Target: client,
+				Outcome: "success", Status: "202"},
+			{Client: client, Action: auditActionPublish, Target: client,
+				Outcome: "failure", Status: "500"},
+		}
+
+		for verbosity, expected := range map[string][]AuditRecord{
+			auditVerbosityFailures: {recs[2]},
+			auditVerbosityChanges:  {recs[0], recs[2]},
+			auditVerbosityAll:      recs,
+		} {
+			Expect(os.RemoveAll(filepath.Join(dir, "audit.log"))).To(Succeed())
+			open(verbosity)
+			for _, rec := range recs {
+				eaaCtx.audit.record(rec)
+			}
+			eaaCtx.audit.close()
+			Expect(records()).To(Equal(expected), verbosity)
+		}
+		eaaCtx.audit = nil
+	})
+
+	g.It("records REST requests", func() {
+		open(auditVerbosityAll)
+
+		router := mux.NewRouter()
+		router.Use(auditMiddleware(eaaCtx))
+		router.Name("SubscribeServiceNotifications").
+			Path("/subscriptions/{urn.namespace}/{urn.id}").
+			HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				writeError(w, r, http.StatusForbidden, ErrorCodeForbidden,
+					"denied")
+			})
+		router.Name("GetServices").Path("/services").
+			HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+		for _, path := range []string{"/subscriptions/ns/sensor", "/services"} {
+			req := httptest.NewRequest("POST", path, nil)
+			req.TLS = clientTLS
+			req.Header.Set(correlationIDHeader, "req-1")
+			router.ServeHTTP(httptest.NewRecorder(), req)
+		}
+
+		Expect(records()).To(Equal([]AuditRecord{{Client: client,
+			Action: auditActionSubscribe, Target: "ns:sensor",
+			Outcome: "failure", Status: "403", CorrelationID: "req-1"}}))
+	})
+
+	g.It("records requests rejected before identifying the client", func() {
+		open(auditVerbosityFailures)
+		eaaCtx.tokenAuth = newTokenAuthenticator(TokenAuthConfig{
+			Issuer:   "https://issuer.example.com",
+			Audience: "eaa",
+			JWKSURL:  "https://issuer.example.com/jwks",
+		})
+
+		router := mux.NewRouter()
+		router.Use(tokenAuthMiddleware(eaaCtx))
+		router.Use(auditMiddleware(eaaCtx))
+		router.Name("RegisterApplication").Path("/services").
+			HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+		req := httptest.NewRequest("POST", "/services", nil)
+		req.TLS = &tls.ConnectionState{}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusUnauthorized))
+
+		addr := &net.TCPAddr{IP: net.IPv4(192, 0, 2, 2), Port: 4321}
+		ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: addr})
+		_, err := unaryTokenAuthenticator(eaaCtx)(ctx, &pb.Service{},
+			&grpc.UnaryServerInfo{FullMethod: "/pb.EAA/RegisterApplication"},
+			func(context.Context, interface{}) (interface{}, error) {
+				return nil, nil
+			})
+		Expect(status.Code(err)).To(Equal(codes.Unauthenticated))
+
+		Expect(records()).To(Equal([]AuditRecord{
+			{Client: req.RemoteAddr, Action: auditActionRegister,
+				Target: req.RemoteAddr, Outcome: "failure", Status: "401"},
+			{Client: addr.String(), Action: auditActionRegister,
+				Target: addr.String(), Outcome: "failure",
+				Status: "Unauthenticated"},
+		}))
+	})
+
+	g.It("upgrades websocket connections", func() {
+		open(auditVerbosityAll)
+
+		router := mux.NewRouter()
+		router.Use(auditMiddleware(eaaCtx))
+		router.Name("GetNotifications").Path("/notifications").
+			HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				conn, err := socket.Upgrade(w, r, nil)
+				if err == nil {
+					conn.Close()
+				}
+			})
+		server := httptest.NewServer(router)
+		defer server.Close()
+
+		client, _, err := websocket.DefaultDialer.Dial(
+			"ws"+strings.TrimPrefix(server.URL, "http")+"/notifications", nil)
+		Expect(err).ShouldNot(HaveOccurred())
+		client.Close()
+	})
+
+	g.It("records gRPC calls", func() {
+		open(auditVerbosityAll)
+
+		ctx := peer.NewContext(context.Background(), &peer.Peer{
+			AuthInfo: credentials.TLSInfo{State: *clientTLS}})
+		intercept := unaryAuditor(eaaCtx)
+
+		_, err := intercept(ctx, &pb.Subscription{Urn: &pb.URN{Namespace: "ns"}},
+			&grpc.UnaryServerInfo{FullMethod: "/pb.EAA/Subscribe"},
+			func(context.Context, interface{}) (interface{}, error) {
+				return nil, nil
+			})
+		Expect(err).ShouldNot(HaveOccurred())
+		_, err = intercept(ctx, &pb.NotificationFromProducer{},
+			&grpc.UnaryServerInfo{FullMethod: "/pb.EAA/PushNotification"},
+			func(context.Context, interface{}) (interface{}, error) {
+				return nil, status.Error(codes.InvalidArgument, "bad")
+			})
+		Expect(err).Should(HaveOccurred())
+
+		Expect(records()).To(Equal([]AuditRecord{
+			{Client: client, Action: auditActionSubscribe, Target: "ns:*",
+				Outcome: "success", Status: "OK"},
+			{Client: client, Action: auditActionPublish, Target: client,
+				Outcome: "failure", Status: "InvalidArgument"},
+		}))
+	})
+})
@@ -3,7 +3,16 @@
 
 package eaa
 
-import "github.com/open-ness/edgenode/pkg/util"
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/open-ness/edgenode/pkg/config"
+	"github.com/open-ness/edgenode/pkg/util"
+)
 
 // CertsInfo describes paths for certs used in configuration
 type CertsInfo struct {
@@ -15,14 +24,277 @@ type CertsInfo struct {
 	KafkaCAPath       string `json:"KafkaCAPath"`
 	KafkaUserCertPath string `json:"KafkaUserCertPath"`
 	KafkaUserKeyPath  string `json:"KafkaUserKeyPath"`
+	CRLPath           string `json:"CRLPath"`
+}
+
+// NotificationBufferConfig describes the store of notifications kept for
+// replay to reconnecting consumers. Size is the number of notifications kept
+// per consumer, 0 disables the store. TTL limits the age of kept
+// notifications, 0 keeps them until replaced by newer ones.
+type NotificationBufferConfig struct {
+	Size int           `json:"Size"`
+	TTL  util.Duration `json:"TTL"`
+}
+
+// KeepaliveConfig describes keepalive of consumer notification connections.
+// EAA pings the consumer every PingInterval and closes the connection if
+// nothing, including the answer to a ping, comes from the consumer within
+// IdleTimeout. Zero values disable pings and the timeout respectively.
+type KeepaliveConfig struct {
+	PingInterval util.Duration `json:"PingInterval"`
+	IdleTimeout  util.Duration `json:"IdleTimeout"`
+}
+
+// ServiceLivenessConfig describes expiration of services. A producer has to
+// register its service again within TTL, otherwise the service is marked
+// expired and it's removed after further GracePeriod. Zero TTL disables
+// expiration, otherwise TTL must be at least a second.
+type ServiceLivenessConfig struct {
+	TTL         util.Duration `json:"TTL"`
+	GracePeriod util.Duration `json:"GracePeriod"`
+}
+
+// DeliveryConfig describes at-least-once delivery of notifications to
+// consumers that connect with acknowledgments. A notification not
+// acknowledged within RetryInterval is sent again, with the interval
+// doubled for every retry, up to MaxRetries times. Zero MaxRetries disables
+// acknowledgments.
+type DeliveryConfig struct {
+	RetryInterval util.Duration `json:"RetryInterval"`
+	MaxRetries    int           `json:"MaxRetries"`
+}
+
+// RateLimitConfig describes limits applied per client certificate Common
+// Name. RequestsPerSecond with bursts of up to RequestBurst requests limits
+// the rate of API requests and MaxConcurrentRequests the number of requests
+// handled at once, a gRPC notification stream counts as a request for its
+// whole duration. NotificationsPerSecond with bursts of up to
+// NotificationBurst notifications limits the rate of notifications
+// published by a producer. Zero values disable the respective limit.
+type RateLimitConfig struct {
+	RequestsPerSecond      float64 `json:"RequestsPerSecond"`
+	RequestBurst           int     `json:"RequestBurst"`
+	MaxConcurrentRequests  int     `json:"MaxConcurrentRequests"`
+	NotificationsPerSecond float64 `json:"NotificationsPerSecond"`
+	NotificationBurst      int     `json:"NotificationBurst"`
+}
+
+// FederationPeer describes EAA of a peer edge node, Endpoint is its
+// TlsEndpoint
+type FederationPeer struct {
+	Name     string `json:"Name"`
+	Endpoint string `json:"Endpoint"`
+}
+
+// FederationConfig describes exchange of services with EAA instances of
+// peer edge nodes. EAA connects to Peers as an application using the client
+// certificate CertPath and key KeyPath, whose Common Name has to be in
+// the eaa-federation namespace, e.g. "eaa-federation:node-1". Catalogs of
+// peers and subscriptions to their services are synchronized every
+// SyncInterval, 30s by default.
+type FederationConfig struct {
+	Peers        []FederationPeer `json:"Peers"`
+	CertPath     string           `json:"CertPath"`
+	KeyPath      string           `json:"KeyPath"`
+	SyncInterval util.Duration    `json:"SyncInterval"`
+}
+
+// NotificationLimitsConfig limits notifications. MaxPayloadSize is the
+// maximum size in bytes of a notification payload published by a producer.
+// Notifications to a consumer are queued up to SendQueueSize notifications
+// and sent in the background, so a slow consumer doesn't hold up the
+// delivery to others. QueueFullPolicy tells what happens to a notification
+// when the queue is full: it's dropped ("drop", the default) or the
+// consumer is disconnected ("disconnect") to reconnect and replay missed
+// notifications. Zero values disable the respective limit, except that
+// gRPC streams and websocket connections replaying notifications are
+// always queued, up to 64 notifications by default.
+type NotificationLimitsConfig struct {
+	MaxPayloadSize  int    `json:"MaxPayloadSize"`
+	SendQueueSize   int    `json:"SendQueueSize"`
+	QueueFullPolicy string `json:"QueueFullPolicy"`
+}
+
+// AuditConfig describes the audit log of registrations, subscriptions and
+// notification publishes appended as JSON lines to the file Path. Verbosity
+// is "failures" to record only failed actions, "changes" (the default) to
+// record also successful registrations and subscriptions, or "all" to
+// record also successful publishes.
+type AuditConfig struct {
+	Path      string `json:"Path"`
+	Verbosity string `json:"Verbosity"`
+}
+
+// TokenAuthConfig describes authentication of applications with JWT bearer
+// tokens, accepted from clients that connect without a client certificate.
+// Tokens have to be signed with RS256, RS384, RS512, ES256, ES384 or ES512
+// by a key of the JWKS at JWKSURL, issued by Issuer and, if set, for
+// Audience. The identity of the application, "namespace:id" as in the
+// Common Name of client certificates, is taken from the claim
+// IdentityClaim, "sub" by default. The JWKS is fetched again every
+// JWKSRefreshInterval, an hour by default, and when a token is signed by
+// an unknown key. Empty JWKSURL disables tokens.
+type TokenAuthConfig struct {
+	Issuer              string        `json:"Issuer"`
+	Audience            string        `json:"Audience"`
+	JWKSURL             string        `json:"JWKSURL"`
+	IdentityClaim       string        `json:"IdentityClaim"`
+	JWKSRefreshInterval util.Duration `json:"JWKSRefreshInterval"`
+}
+
+// AccessRule allows applications with client certificate Common Name
+// matching Client pattern to perform Actions on services with URN matching
+// Target pattern. Patterns use path.Match syntax on "namespace:id" strings,
+// e.g. "vendor-a:*" matches all applications in the vendor-a namespace.
+// Empty Target matches any service.
+type AccessRule struct {
+	Client  string   `json:"Client"`
+	Actions []string `json:"Actions"`
+	Target  string   `json:"Target"`
+}
+
+// AccessControlConfig describes access control of applications. When
+// enabled, an action is allowed only if any of the rules allows it,
+// otherwise all actions are allowed.
+type AccessControlConfig struct {
+	Enabled bool         `json:"Enabled"`
+	Rules   []AccessRule `json:"Rules"`
 }
 
 // Config describes EAA JSON config file
 type Config struct {
-	TLSEndpoint        string        `json:"TlsEndpoint"`
-	OpenEndpoint       string        `json:"OpenEndpoint"`
-	ValidationEndpoint string        `json:"ValidationEndpoint"`
-	HeartbeatInterval  util.Duration `json:"HeartbeatInterval"`
-	Certs              CertsInfo     `json:"Certs"`
-	KafkaBroker        string        `json:"KafkaBroker"`
+	TLSEndpoint        string                   `json:"TlsEndpoint"`
+	OpenEndpoint       string                   `json:"OpenEndpoint"`
+	GrpcEndpoint       string                   `json:"GrpcEndpoint"`
+	ValidationEndpoint string                   `json:"ValidationEndpoint"`
+	HeartbeatInterval  util.Duration            `json:"HeartbeatInterval"`
+	Certs              CertsInfo                `json:"Certs"`
+	KafkaBroker        string                   `json:"KafkaBroker"`
+	NotificationBuffer NotificationBufferConfig `json:"NotificationBuffer"`
+	StatePath          string                   `json:"StatePath"`
+	Keepalive          KeepaliveConfig          `json:"Keepalive"`
+	ServiceLiveness    ServiceLivenessConfig    `json:"ServiceLiveness"`
+	AccessControl      AccessControlConfig      `json:"AccessControl"`
+	RateLimit          RateLimitConfig          `json:"RateLimit"`
+	Federation         FederationConfig         `json:"Federation"`
+	Delivery           DeliveryConfig           `json:"Delivery"`
+	Audit              AuditConfig              `json:"Audit"`
+	NotificationLimits NotificationLimitsConfig `json:"NotificationLimits"`
+	TokenAuth          TokenAuthConfig          `json:"TokenAuth"`
+}
+
+// Validate checks the configuration and returns an error listing all
+// problems found
+func (c *Config) Validate() error {
+	var v config.Validator
+
+	v.ListenEndpoint("TlsEndpoint", c.TLSEndpoint)
+	if c.OpenEndpoint != "" {
+		v.ListenEndpoint("OpenEndpoint", c.OpenEndpoint)
+	}
+	if c.GrpcEndpoint != "" {
+		v.ListenEndpoint("GrpcEndpoint", c.GrpcEndpoint)
+	}
+	v.Check(c.HeartbeatInterval.Duration >= 0,
+		"HeartbeatInterval: must not be negative")
+	v.Check(c.NotificationBuffer.Size >= 0,
+		"NotificationBuffer.Size: must not be negative")
+	v.Check(c.NotificationBuffer.TTL.Duration >= 0,
+		"NotificationBuffer.TTL: must not be negative")
+	v.Check(c.Keepalive.PingInterval.Duration >= 0,
+		"Keepalive.PingInterval: must not be negative")
+	v.Check(c.Keepalive.IdleTimeout.Duration == 0 ||
+		c.Keepalive.IdleTimeout.Duration > c.Keepalive.PingInterval.Duration,
+		"Keepalive.IdleTimeout: must be greater than PingInterval")
+	v.Check(c.ServiceLiveness.TTL.Duration == 0 ||
+		c.ServiceLiveness.TTL.Duration >= minServiceLivenessTTL,
+		"ServiceLiveness.TTL: must be zero or at least %v",
+		minServiceLivenessTTL)
+	v.Check(c.ServiceLiveness.GracePeriod.Duration >= 0,
+		"ServiceLiveness.GracePeriod: must not be negative")
+	v.Check(c.RateLimit.RequestsPerSecond >= 0,
+		"RateLimit.RequestsPerSecond: must not be negative")
+	v.Check(c.RateLimit.RequestBurst >= 0,
+		"RateLimit.RequestBurst: must not be negative")
+	v.Check(c.RateLimit.MaxConcurrentRequests >= 0,
+		"RateLimit.MaxConcurrentRequests: must not be negative")
+	v.Check(c.RateLimit.NotificationsPerSecond >= 0,
+		"RateLimit.NotificationsPerSecond: must not be negative")
+	v.Check(c.RateLimit.NotificationBurst >= 0,
+		"RateLimit.NotificationBurst: must not be negative")
+	v.Check(c.Delivery.MaxRetries >= 0,
+		"Delivery.MaxRetries: must not be negative")
+	v.Check(c.Delivery.MaxRetries == 0 || c.Delivery.RetryInterval.Duration > 0,
+		"Delivery.RetryInterval: must be positive when retries are enabled")
+	v.Check(c.NotificationLimits.MaxPayloadSize >= 0,
+		"NotificationLimits.MaxPayloadSize: must not be negative")
+	v.Check(c.NotificationLimits.SendQueueSize >= 0,
+		"NotificationLimits.SendQueueSize: must not be negative")
+	v.Check(c.NotificationLimits.QueueFullPolicy == "" ||
+		c.NotificationLimits.QueueFullPolicy == queueFullPolicyDrop ||
+		c.NotificationLimits.QueueFullPolicy == queueFullPolicyDisconnect,
+		"NotificationLimits.QueueFullPolicy: unknown policy %q",
+		c.NotificationLimits.QueueFullPolicy)
+	v.Check(c.Audit.Verbosity == "" ||
+		c.Audit.Verbosity == auditVerbosityFailures ||
+		c.Audit.Verbosity == auditVerbosityChanges ||
+		c.Audit.Verbosity == auditVerbosityAll,
+		"Audit.Verbosity: unknown verbosity %q", c.Audit.Verbosity)
+	if c.Audit.Path != "" {
+		dir, err := os.Stat(filepath.Dir(c.Audit.Path))
+		v.Check(err == nil && dir.IsDir(),
+			"Audit.Path: directory of %s does not exist", c.Audit.Path)
+	}
+	if c.TokenAuth.JWKSURL != "" {
+		u, err := url.Parse(c.TokenAuth.JWKSURL)
+		v.Check(err == nil && (u.Scheme == "https" || u.Scheme == "http") &&
+			u.Host != "",
+			"TokenAuth.JWKSURL: invalid URL %q", c.TokenAuth.JWKSURL)
+		v.Check(c.TokenAuth.Issuer != "",
+			"TokenAuth.Issuer: value is required")
+		v.Check(c.TokenAuth.JWKSRefreshInterval.Duration >= 0,
+			"TokenAuth.JWKSRefreshInterval: must not be negative")
+	}
+	if c.StatePath != "" {
+		dir, err := os.Stat(filepath.Dir(c.StatePath))
+		v.Check(err == nil && dir.IsDir(),
+			"StatePath: directory of %s does not exist", c.StatePath)
+	}
+	for i, rule := range c.AccessControl.Rules {
+		_, err := path.Match(rule.Client, "")
+		v.Check(rule.Client != "" && err == nil,
+			"AccessControl.Rules[%d].Client: invalid pattern %q", i, rule.Client)
+		_, err = path.Match(rule.Target, "")
+		v.Check(err == nil,
+			"AccessControl.Rules[%d].Target: invalid pattern %q", i, rule.Target)
+		v.Check(len(rule.Actions) != 0,
+			"AccessControl.Rules[%d].Actions: value is required", i)
+		for _, a := range rule.Actions {
+			v.Check(a == accessActionRegister || a == accessActionDiscover ||
+				a == accessActionSubscribe,
+				"AccessControl.Rules[%d].Actions: unknown action %q", i, a)
+		}
+	}
+	if len(c.Federation.Peers) > 0 {
+		names := make(map[string]bool)
+		for i, peer := range c.Federation.Peers {
+			v.Check(peer.Name != "" && !names[peer.Name],
+				"Federation.Peers[%d].Name: value is required and must be unique", i)
+			names[peer.Name] = true
+			v.Endpoint(fmt.Sprintf("Federation.Peers[%d].Endpoint", i),
+				peer.Endpoint)
+		}
+		v.File("Federation.CertPath", c.Federation.CertPath)
+		v.File("Federation.KeyPath", c.Federation.KeyPath)
+		v.Check(c.Federation.SyncInterval.Duration >= 0,
+			"Federation.SyncInterval: must not be negative")
+	}
+	v.File("Certs.CaRootPath", c.Certs.CaRootPath)
+	v.File("Certs.ServerCertPath", c.Certs.ServerCertPath)
+	v.File("Certs.ServerKeyPath", c.Certs.ServerKeyPath)
+	if c.Certs.CRLPath != "" {
+		v.File("Certs.CRLPath", c.Certs.CRLPath)
+	}
+
+	return v.Err()
 }
@@ -0,0 +1,219 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package eaa
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// maxRetryBackoffShift caps the exponential backoff of retries at 64 times
+// RetryInterval
+const maxRetryBackoffShift = 6
+
+// NotificationAck is sent over the websocket by a consumer connected with
+// acknowledgments to acknowledge the notification with sequence number Ack
+type NotificationAck struct {
+	Ack uint64 `json:"ack"`
+}
+
+// DeliveryStatistics describes delivery of notifications of a producer to
+// consumers acknowledging them
+type DeliveryStatistics struct {
+	// Notifications sent for the first time
+	Sent uint64 `json:"sent"`
+	// Notifications acknowledged by consumers
+	Acknowledged uint64 `json:"acknowledged"`
+	// Notifications sent again as not acknowledged in time
+	Retried uint64 `json:"retried"`
+	// Notifications given up after all retries or on disconnection
+	Failed uint64 `json:"failed"`
+}
+
+// deliveryStats keeps DeliveryStatistics of producers. A nil deliveryStats
+// keeps nothing.
+type deliveryStats struct {
+	sync.Mutex
+	m map[string]*DeliveryStatistics
+}
+
+func newDeliveryStats() *deliveryStats {
+	return &deliveryStats{m: make(map[string]*DeliveryStatistics)}
+}
+
+func (s *deliveryStats) update(producer string, f func(*DeliveryStatistics)) {
+	if s == nil {
+		return
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	stats, found := s.m[producer]
+	if !found {
+		stats = &DeliveryStatistics{}
+		s.m[producer] = stats
+	}
+	f(stats)
+}
+
+// get returns statistics of the producer
+func (s *deliveryStats) get(producer string) DeliveryStatistics {
+	if s == nil {
+		return DeliveryStatistics{}
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	if stats, found := s.m[producer]; found {
+		return *stats
+	}
+	return DeliveryStatistics{}
+}
+
+// pendingDelivery is a notification sent and not acknowledged yet
+type pendingDelivery struct {
+	producer string
+	payload  []byte
+	attempts int
+	next     time.Time
+}
+
+// pendingDeliveries tracks notifications sent over the websocket
+// connection of a consumer acknowledging them. It also serializes writes
+// to the connection as retries are sent concurrently with new
+// notifications.
+type pendingDeliveries struct {
+	sync.Mutex
+	writeLock sync.Mutex
+	m         map[uint64]*pendingDelivery
+}
+
+func newPendingDeliveries() *pendingDeliveries {
+	return &pendingDeliveries{m: make(map[uint64]*pendingDelivery)}
+}
+
+// send sends the notification with sequence number seq and tracks it until
+// acknowledged. A notification that fails to be sent is retried as well.
+func (p *pendingDeliveries) send(conn *websocket.Conn, seq uint64,
+	payload []byte, eaaCtx *Context) error {
+
+	var notif struct {
+		URN URN `json:"producer"`
+	}
+	if err := json.Unmarshal(payload, &notif); err != nil {
+		log.Errf("Failed to decode producer of notification: %v", err)
+	}
+	producer := notif.URN.String()
+
+	p.Lock()
+	p.m[seq] = &pendingDelivery{
+		producer: producer,
+		payload:  payload,
+		next:     time.Now().Add(eaaCtx.cfg.Delivery.RetryInterval.Duration),
+	}
+	p.Unlock()
+	eaaCtx.deliveryStats.update(producer, func(s *DeliveryStatistics) {
+		s.Sent++
+	})
+
+	p.writeLock.Lock()
+	defer p.writeLock.Unlock()
+	return conn.WriteMessage(websocket.TextMessage, payload)
+}
+
+// ack handles an acknowledgment message of the consumer
+func (p *pendingDeliveries) ack(data []byte, commonName string,
+	eaaCtx *Context) {
+
+	var ack NotificationAck
+	if err := json.Unmarshal(data, &ack); err != nil || ack.Ack == 0 {
+		log.Debugf("Ignoring message from %s that is not an ack", commonName)
+		return
+	}
+
+	p.Lock()
+	pending, found := p.m[ack.Ack]
+	delete(p.m, ack.Ack)
+	p.Unlock()
+
+	if found {
+		eaaCtx.deliveryStats.update(pending.producer,
+			func(s *DeliveryStatistics) { s.Acknowledged++ })
+	}
+}
+
+// retry sends again notifications not acknowledged in time, with
+// exponential backoff, and gives up on those retried MaxRetries times
+func (p *pendingDeliveries) retry(conn *websocket.Conn, commonName string,
+	eaaCtx *Context) {
+
+	interval := eaaCtx.cfg.Delivery.RetryInterval.Duration
+	now := time.Now()
+
+	var resend [][]byte
+	p.Lock()
+	for seq, pending := range p.m {
+		if now.Before(pending.next) {
+			continue
+		}
+		if pending.attempts >= eaaCtx.cfg.Delivery.MaxRetries {
+			delete(p.m, seq)
+			log.Warningf("Notification %d to %s not acknowledged", seq,
+				commonName)
+			eaaCtx.deliveryStats.update(pending.producer,
+				func(s *DeliveryStatistics) { s.Failed++ })
+			continue
+		}
+
+		pending.attempts++
+		shift := pending.attempts
+		if shift > maxRetryBackoffShift {
+			shift = maxRetryBackoffShift
+		}
+		pending.next = now.Add(interval << uint(shift))
+		resend = append(resend, pending.payload)
+		eaaCtx.deliveryStats.update(pending.producer,
+			func(s *DeliveryStatistics) { s.Retried++ })
+	}
+	p.Unlock()
+
+	p.writeLock.Lock()
+	defer p.writeLock.Unlock()
+	for _, payload := range resend {
+		if err := conn.WriteMessage(websocket.TextMessage, payload); err != nil {
+			log.Debugf("Failed to retry notification to %s: %v", commonName,
+				err)
+			return
+		}
+	}
+}
+
+// retryUntilDone retries notifications until done is closed and then
+// gives up on all pending ones
+func (p *pendingDeliveries) retryUntilDone(conn *websocket.Conn,
+	commonName string, done <-chan struct{}, eaaCtx *Context) {
+
+	ticker := time.NewTicker(eaaCtx.cfg.Delivery.RetryInterval.Duration)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			p.Lock()
+			for seq, pending := range p.m {
+				delete(p.m, seq)
+				eaaCtx.deliveryStats.update(pending.producer,
+					func(s *DeliveryStatistics) { s.Failed++ })
+			}
+			p.Unlock()
+			return
+		case <-ticker.C:
+			p.retry(conn, commonName, eaaCtx)
+		}
+	}
+}
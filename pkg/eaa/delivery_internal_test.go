@@ -0,0 +1,131 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package eaa
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	g "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/open-ness/edgenode/pkg/util"
+)
+
+var _ = g.Describe("pendingDeliveries", func() {
+	const (
+		consumer = "ns:consumer"
+		producer = "ns:producer"
+	)
+
+	var (
+		eaaCtx   *Context
+		server   *httptest.Server
+		client   *websocket.Conn
+		received chan NotificationToConsumer
+	)
+
+	send := func(seq uint64) {
+		payload, err := json.Marshal(NotificationToConsumer{
+			Name:     "event",
+			Version:  "1.0.0",
+			URN:      URN{Namespace: "ns", ID: "producer"},
+			Sequence: seq,
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(sendNotificationToSubscriber(consumer, seq, payload,
+			eaaCtx)).To(Succeed())
+	}
+
+	stats := func() DeliveryStatistics {
+		return eaaCtx.deliveryStats.get(producer)
+	}
+
+	g.BeforeEach(func() {
+		eaaCtx = &Context{}
+		eaaCtx.consumerConnections = consumerConns{m: make(map[string]ConsumerConnection)}
+		eaaCtx.cfg.Delivery = DeliveryConfig{
+			RetryInterval: util.Duration{Duration: 30 * time.Millisecond},
+			MaxRetries:    2,
+		}
+		eaaCtx.deliveryStats = newDeliveryStats()
+
+		connected := make(chan struct{})
+		server = httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				conn, err := socket.Upgrade(w, r, nil)
+				if err != nil {
+					return
+				}
+				eaaCtx.consumerConnections.Lock()
+				eaaCtx.consumerConnections.m[consumer] = ConsumerConnection{
+					connection: conn, acks: newPendingDeliveries()}
+				eaaCtx.consumerConnections.Unlock()
+				go watchWsConn(conn, consumer, nil, eaaCtx)
+				close(connected)
+			}))
+
+		var err error
+		client, _, err = websocket.DefaultDialer.Dial(
+			"ws"+strings.TrimPrefix(server.URL, "http"), nil)
+		Expect(err).ShouldNot(HaveOccurred())
+		Eventually(connected).Should(BeClosed())
+
+		received = make(chan NotificationToConsumer, 10)
+		go func() {
+			for {
+				var notif NotificationToConsumer
+				if err := client.ReadJSON(&notif); err != nil {
+					return
+				}
+				received <- notif
+			}
+		}()
+	})
+
+	g.AfterEach(func() {
+		client.Close()
+		server.Close()
+	})
+
+	g.It("stops sending an acknowledged notification", func() {
+		send(7)
+
+		var notif NotificationToConsumer
+		Eventually(received).Should(Receive(&notif))
+		Expect(notif.Sequence).To(BeEquivalentTo(7))
+		Expect(client.WriteJSON(NotificationAck{Ack: 7})).To(Succeed())
+
+		Eventually(stats).Should(Equal(DeliveryStatistics{Sent: 1,
+			Acknowledged: 1}))
+		Consistently(received, 200*time.Millisecond).ShouldNot(Receive())
+	})
+
+	g.It("retries an unacknowledged notification and gives up", func() {
+		send(7)
+
+		for i := 0; i < 3; i++ {
+			var notif NotificationToConsumer
+			Eventually(received, time.Second).Should(Receive(&notif))
+			Expect(notif.Sequence).To(BeEquivalentTo(7))
+		}
+
+		Eventually(stats, time.Second).Should(Equal(DeliveryStatistics{
+			Sent: 1, Retried: 2, Failed: 1}))
+		Expect(received).ToNot(Receive())
+	})
+
+	g.It("gives up pending notifications of a closed connection", func() {
+		send(7)
+		Eventually(received).Should(Receive())
+
+		client.Close()
+		Eventually(stats).Should(Equal(DeliveryStatistics{Sent: 1,
+			Failed: 1}))
+	})
+})
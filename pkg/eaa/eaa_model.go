@@ -13,6 +13,9 @@ type NotificationDescriptor struct {
 	Version string `json:"version,omitempty"`
 	// Human readable description of notification
 	Description string `json:"description,omitempty"`
+	// JSON Schema of the notification payload, payloads of notifications
+	// published by the producer are validated against it
+	Schema json.RawMessage `json:"schema,omitempty"`
 }
 
 // NotificationFromProducer describes a type used in EAA API
@@ -37,6 +40,10 @@ type NotificationToConsumer struct {
 	Payload json.RawMessage `json:"payload,omitempty"`
 	// URN of the producer
 	URN URN `json:"producer,omitempty"`
+	// Sequence number of notification, increasing across all notifications
+	// of EAA. It's set only when notifications are buffered for replay or
+	// delivered with acknowledgments.
+	Sequence uint64 `json:"sequence,omitempty"`
 }
 
 // NotificationMessage is a message sent/received by a message broker
@@ -47,7 +54,8 @@ type NotificationMessage struct {
 
 // ServiceList JSON struct
 type ServiceList struct {
-	Services []Service `json:"services,omitempty"`
+	Services      []Service `json:"services,omitempty"`
+	NextPageToken string    `json:"next_page_token,omitempty"`
 }
 
 // Service JSON struct
@@ -58,6 +66,10 @@ type Service struct {
 	Status        string                   `json:"status,omitempty"`
 	Notifications []NotificationDescriptor `json:"notifications,omitempty"`
 	Info          json.RawMessage          `json:"info,omitempty"`
+	Tags          []string                 `json:"tags,omitempty"`
+	// Node is the federation peer hosting the service, empty for services
+	// of the local node
+	Node string `json:"node,omitempty"`
 }
 
 // ServiceMessage is a message sent/received by a message broker
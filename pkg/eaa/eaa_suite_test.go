@@ -72,13 +72,16 @@ type EAATestSuiteConfig struct {
 	Dir                 string           `json:"Dir"`
 	TLSEndpoint         string           `json:"TlsEndpoint"`
 	ValidationEndpoint  string           `json:"ValidationEndpoint"`
+	OpenEndpoint        string           `json:"OpenEndpoint"`
+	GrpcEndpoint        string           `json:"GrpcEndpoint"`
 	ApplianceTimeoutSec int              `json:"Timeout"`
 	MsgBrokerBackend    MsgBrokerBackend `json:"MsgBrokerBackend"`
 }
 
 // test suite config with default values
 var cfg = EAATestSuiteConfig{"../../", "localhost:48080",
-	"localhost:42555", 2, MsgBrokerBackend{GochannelsBackend, ""}}
+	"localhost:42555", "localhost:48081", "localhost:48082", 2,
+	MsgBrokerBackend{GochannelsBackend, ""}}
 
 func readConfig(path string) {
 	if path != "" {
@@ -270,6 +273,8 @@ func generateConfigs() {
 	eaaCfg := []byte(`{
 		"TlsEndpoint": "` + cfg.TLSEndpoint + `",
 		"ValidationEndpoint": "` + cfg.ValidationEndpoint + `",
+		"OpenEndpoint": "` + cfg.OpenEndpoint + `",
+		"GrpcEndpoint": "` + cfg.GrpcEndpoint + `",
 		"Certs": {
 			"CaRootKeyPath": "` + tempConfCaRootKeyPath + `",
 			"CaRootPath": "` + tempConfCaRootPath + `",
@@ -7,10 +7,26 @@ import (
 	"github.com/gorilla/websocket"
 )
 
-// ConsumerConnection stores websocket connection of a consumer
+// ConsumerConnection stores websocket or gRPC stream connection of a
+// consumer
 type ConsumerConnection struct {
 
 	// The details of the websocket connection between the agent and the
 	// consumer app.
 	connection *websocket.Conn
+
+	// Notifications queued for the consumer app connected over a gRPC
+	// stream, nil for websocket connections.
+	notifications chan []byte
+
+	// Ends the gRPC stream of the consumer app with the reason.
+	closeStream func(reason error)
+
+	// Notifications waiting for acknowledgment by the consumer app, nil
+	// if the consumer doesn't acknowledge notifications.
+	acks *pendingDeliveries
+
+	// Notifications queued for sending over the websocket connection, nil
+	// if they are sent right away.
+	queue chan queuedNotification
 }
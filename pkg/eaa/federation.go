@@ -0,0 +1,397 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package eaa
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+)
+
+// federationNamespace is the namespace of Common Names of client
+// certificates used by EAA to connect to federation peers
+const federationNamespace = "eaa-federation"
+
+const (
+	defaultFederationSyncInterval = 30 * time.Second
+	federationRequestTimeout      = 10 * time.Second
+)
+
+// isFederationPeer checks if the client is EAA of a federation peer
+func isFederationPeer(commonName string) bool {
+	return strings.HasPrefix(commonName, federationNamespace+":")
+}
+
+// federation exchanges services with EAA instances of peer edge nodes.
+// Services of peers are listed by GetServices with the Node field set to
+// the name of the peer. Subscriptions of local consumers to services of
+// a peer are made on the peer by the local EAA, which then delivers
+// notifications received from the peer to the consumers. A nil federation
+// has no peers.
+type federation struct {
+	interval time.Duration
+	peers    []*federationPeer
+}
+
+// federatedSubscription is a subscription made on a peer, id is empty for
+// a subscription to a namespace
+type federatedSubscription struct {
+	namespace string
+	id        string
+	notif     UniqueNotif
+}
+
+// federationPeer keeps the catalog of a peer and subscriptions made on it.
+// The lock guards services and conn, subscriptions are used only by sync.
+type federationPeer struct {
+	sync.Mutex
+	name          string
+	baseURL       string
+	wsURL         string
+	wsHeader      http.Header
+	client        *http.Client
+	dialer        *websocket.Dialer
+	services      []Service
+	subscriptions map[federatedSubscription]bool
+	conn          *websocket.Conn
+}
+
+func newFederation(cfg FederationConfig, caPath,
+	serverName string) (*federation, error) {
+	cert, err := tls.LoadX509KeyPair(cfg.CertPath, cfg.KeyPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to load federation key pair")
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to parse federation certificate")
+	}
+	if !isFederationPeer(leaf.Subject.CommonName) {
+		return nil, errors.Errorf(
+			"Federation certificate Common Name %s is not in %s namespace",
+			leaf.Subject.CommonName, federationNamespace)
+	}
+
+	caCerts, err := ioutil.ReadFile(filepath.Clean(caPath))
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to read CA root certificates")
+	}
+	certPool := x509.NewCertPool()
+	if !certPool.AppendCertsFromPEM(caCerts) {
+		return nil, errors.New("Failed to append cert to pool")
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      certPool,
+		ServerName:   serverName,
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	f := &federation{interval: cfg.SyncInterval.Duration}
+	if f.interval == 0 {
+		f.interval = defaultFederationSyncInterval
+	}
+	for _, peer := range cfg.Peers {
+		f.peers = append(f.peers, newFederationPeer(peer.Name, peer.Endpoint,
+			leaf.Subject.CommonName, tlsConfig))
+	}
+	return f, nil
+}
+
+// newFederationPeer creates the peer connecting as commonName, the Common
+// Name of the client certificate of tlsConfig
+func newFederationPeer(name, endpoint, commonName string,
+	tlsConfig *tls.Config) *federationPeer {
+	return &federationPeer{
+		name:    name,
+		baseURL: "https://" + endpoint,
+		wsURL:   "wss://" + endpoint + "/notifications",
+		// EAA accepts a notifications connection only with the Host
+		// header matching the Common Name of the consumer
+		wsHeader: http.Header{"Host": []string{commonName}},
+		client: &http.Client{
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+			Timeout:   federationRequestTimeout,
+		},
+		dialer: &websocket.Dialer{
+			TLSClientConfig:  tlsConfig,
+			HandshakeTimeout: federationRequestTimeout,
+		},
+		subscriptions: make(map[federatedSubscription]bool),
+	}
+}
+
+// run synchronizes with peers until ctx is done
+func (f *federation) run(ctx context.Context, eaaCtx *Context) {
+	if f == nil {
+		return
+	}
+
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+	for {
+		for _, peer := range f.peers {
+			peer.sync(eaaCtx)
+		}
+
+		select {
+		case <-ctx.Done():
+			for _, peer := range f.peers {
+				peer.close()
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// services returns services of all peers
+func (f *federation) services() []Service {
+	if f == nil {
+		return nil
+	}
+
+	var services []Service
+	for _, peer := range f.peers {
+		peer.Lock()
+		services = append(services, peer.services...)
+		peer.Unlock()
+	}
+	return services
+}
+
+// sync fetches the catalog of the peer, connects to its notifications and
+// updates subscriptions made on it to match subscriptions of local
+// consumers to its services
+func (p *federationPeer) sync(eaaCtx *Context) {
+	// Requests to the peer are made without the lock, so a slow peer
+	// doesn't hold up listing of services
+	services, err := p.fetchServices()
+	p.Lock()
+	p.services = services
+	connected := p.conn != nil
+	p.Unlock()
+	if err != nil {
+		log.Errf("Federation peer %s: %v", p.name, err)
+		return
+	}
+
+	if !connected {
+		if err = p.connect(eaaCtx); err != nil {
+			log.Errf("Federation peer %s: %v", p.name, err)
+			return
+		}
+	}
+
+	desired := p.desiredSubscriptions(services, eaaCtx)
+	for sub, desc := range desired {
+		if p.subscriptions[sub] {
+			continue
+		}
+		if err := p.updateSubscription(http.MethodPost, sub, desc); err != nil {
+			log.Errf("Federation peer %s: %v", p.name, err)
+			continue
+		}
+		p.subscriptions[sub] = true
+	}
+	for sub := range p.subscriptions {
+		if _, found := desired[sub]; found {
+			continue
+		}
+		desc := NotificationDescriptor{Name: sub.notif.notifName,
+			Version: sub.notif.notifVersion}
+		if err := p.updateSubscription(http.MethodDelete, sub, desc); err != nil {
+			log.Errf("Federation peer %s: %v", p.name, err)
+			continue
+		}
+		delete(p.subscriptions, sub)
+	}
+}
+
+// fetchServices gets services registered on the peer, services the peer
+// knows from its own peers are left out
+func (p *federationPeer) fetchServices() ([]Service, error) {
+	resp, err := p.client.Get(p.baseURL + "/services")
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get services")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("Failed to get services: %s", resp.Status)
+	}
+	var list ServiceList
+	if err = json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, errors.Wrap(err, "Failed to decode services")
+	}
+
+	var services []Service
+	for _, serv := range list.Services {
+		if serv.Node != "" || serv.URN == nil {
+			continue
+		}
+		serv.Node = p.name
+		services = append(services, serv)
+	}
+	return services, nil
+}
+
+// connect opens the notifications connection to the peer. Subscriptions
+// are made again on a new connection, as the peer may have lost them.
+func (p *federationPeer) connect(eaaCtx *Context) error {
+	conn, _, err := p.dialer.Dial(p.wsURL, p.wsHeader)
+	if err != nil {
+		return errors.Wrap(err, "Failed to connect to notifications")
+	}
+	p.Lock()
+	p.conn = conn
+	p.Unlock()
+	p.subscriptions = make(map[federatedSubscription]bool)
+	log.Infof("Connected to notifications of federation peer %s", p.name)
+
+	go p.receive(conn, eaaCtx)
+	return nil
+}
+
+// receive delivers notifications from the peer to local consumers until
+// the connection is closed
+func (p *federationPeer) receive(conn *websocket.Conn, eaaCtx *Context) {
+	defer func() {
+		p.Lock()
+		if p.conn == conn {
+			p.conn = nil
+		}
+		p.Unlock()
+		conn.Close()
+	}()
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			log.Infof("Notifications of federation peer %s closed: %v",
+				p.name, err)
+			return
+		}
+
+		var notif NotificationToConsumer
+		if err = json.Unmarshal(data, &notif); err != nil {
+			log.Errf("Federation peer %s sent invalid notification: %v",
+				p.name, err)
+			continue
+		}
+
+		// Notifications are not passed to other peers, they get them from
+		// the producer's node
+		err = deliverNotification(notif.URN, &NotificationFromProducer{
+			Name:    notif.Name,
+			Version: notif.Version,
+			Payload: notif.Payload,
+		}, false, eaaCtx)
+		if err != nil {
+			log.Errf("Failed to deliver notification of federation peer %s: %v",
+				p.name, err)
+		}
+	}
+}
+
+// desiredSubscriptions returns subscriptions of local consumers to
+// notifications offered by services of the peer
+func (p *federationPeer) desiredSubscriptions(services []Service,
+	eaaCtx *Context) map[federatedSubscription]NotificationDescriptor {
+
+	offers := func(serv *Service, key UniqueNotif) bool {
+		for _, n := range serv.Notifications {
+			if matchesSubscription(key.notifName, n.Name) &&
+				matchesSubscription(key.notifVersion, n.Version) {
+				return true
+			}
+		}
+		return false
+	}
+	hasLocal := func(subIDs SubscriberIds) bool {
+		for _, subID := range subIDs {
+			if !isFederationPeer(subID) {
+				return true
+			}
+		}
+		return false
+	}
+
+	desired := make(map[federatedSubscription]NotificationDescriptor)
+
+	eaaCtx.subscriptionInfo.RLock()
+	defer eaaCtx.subscriptionInfo.RUnlock()
+
+	for key, conSub := range eaaCtx.subscriptionInfo.m {
+		desc := NotificationDescriptor{Name: key.notifName,
+			Version: key.notifVersion}
+		for i := range services {
+			serv := &services[i]
+			if serv.URN.Namespace != key.namespace || !offers(serv, key) {
+				continue
+			}
+			if hasLocal(conSub.namespaceSubscriptions) {
+				desired[federatedSubscription{namespace: key.namespace,
+					notif: key}] = desc
+			}
+			for serviceID, subIDs := range conSub.serviceSubscriptions {
+				if matchesSubscription(serviceID, serv.URN.ID) &&
+					hasLocal(subIDs) {
+					desired[federatedSubscription{namespace: key.namespace,
+						id: serv.URN.ID, notif: key}] = desc
+				}
+			}
+		}
+	}
+	return desired
+}
+
+// updateSubscription subscribes (POST) or unsubscribes (DELETE) on the peer
+func (p *federationPeer) updateSubscription(method string,
+	sub federatedSubscription, desc NotificationDescriptor) error {
+	url := p.baseURL + "/subscriptions/" + sub.namespace
+	if sub.id != "" {
+		url += "/" + sub.id
+	}
+
+	body, err := json.Marshal([]NotificationDescriptor{desc})
+	if err != nil {
+		return errors.Wrap(err, "Failed to marshal subscription")
+	}
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "Failed to create subscription request")
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to %s %s", method, url)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.Errorf("Failed to %s %s: %s", method, url, resp.Status)
+	}
+	return nil
+}
+
+func (p *federationPeer) close() {
+	p.Lock()
+	defer p.Unlock()
+	if p.conn != nil {
+		p.conn.Close()
+		p.conn = nil
+	}
+}
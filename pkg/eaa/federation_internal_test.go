@@ -0,0 +1,213 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package eaa
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	g "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = g.Describe("federationPeer", func() {
+	const (
+		consCN      = "ns1:consumer"
+		otherPeerCN = "eaa-federation:node-3"
+		federateCN  = "eaa-federation:node-1"
+	)
+
+	var (
+		eaaCtx *Context
+		server *httptest.Server
+		peer   *federationPeer
+
+		lock     sync.Mutex
+		requests []string
+		peerConn *websocket.Conn
+	)
+
+	event := []NotificationDescriptor{{Name: "event", Version: "1.0.0"}}
+
+	recorded := func() []string {
+		lock.Lock()
+		defer lock.Unlock()
+		return append([]string(nil), requests...)
+	}
+
+	g.BeforeEach(func() {
+		requests = nil
+		peerConn = nil
+
+		eaaCtx = &Context{}
+		eaaCtx.serviceInfo = services{m: make(map[string]Service)}
+		eaaCtx.consumerConnections = consumerConns{m: make(map[string]ConsumerConnection)}
+		eaaCtx.subscriptionInfo = NotificationSubscriptions{
+			m: make(map[UniqueNotif]*ConsumerSubscription)}
+		eaaCtx.notificationBuffer = newNotificationStore(
+			NotificationBufferConfig{Size: 10})
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("/services", func(w http.ResponseWriter, r *http.Request) {
+			Expect(json.NewEncoder(w).Encode(ServiceList{Services: []Service{
+				{URN: &URN{Namespace: "ns1", ID: "remote"},
+					Notifications: event},
+				{URN: &URN{Namespace: "ns1", ID: "far"},
+					Notifications: event, Node: "node-3"},
+			}})).To(Succeed())
+		})
+		mux.HandleFunc("/subscriptions/", func(w http.ResponseWriter, r *http.Request) {
+			lock.Lock()
+			requests = append(requests, r.Method+" "+r.URL.Path)
+			lock.Unlock()
+			w.WriteHeader(http.StatusCreated)
+		})
+		mux.HandleFunc("/notifications", func(w http.ResponseWriter, r *http.Request) {
+			if r.Host != federateCN {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			conn, err := socket.Upgrade(w, r, nil)
+			if err != nil {
+				return
+			}
+			lock.Lock()
+			peerConn = conn
+			lock.Unlock()
+		})
+		server = httptest.NewTLSServer(mux)
+
+		certPool := x509.NewCertPool()
+		certPool.AddCert(server.Certificate())
+		peer = newFederationPeer("node-2", server.Listener.Addr().String(),
+			federateCN, &tls.Config{RootCAs: certPool})
+	})
+
+	g.AfterEach(func() {
+		peer.close()
+		server.Close()
+	})
+
+	g.It("lists services of the peer", func() {
+		peer.sync(eaaCtx)
+
+		f := &federation{peers: []*federationPeer{peer}}
+		services := f.services()
+		Expect(services).To(HaveLen(1))
+		Expect(services[0].URN.String()).To(Equal("ns1:remote"))
+		Expect(services[0].Node).To(Equal("node-2"))
+		Expect(recorded()).To(BeEmpty())
+	})
+
+	g.It("subscribes on the peer for local consumers", func() {
+		Expect(addSubscriptionToNamespace(otherPeerCN, "ns1", event,
+			eaaCtx)).To(Succeed())
+		peer.sync(eaaCtx)
+		Expect(recorded()).To(BeEmpty())
+
+		Expect(addSubscriptionToNamespace(consCN, "ns1", event,
+			eaaCtx)).To(Succeed())
+		peer.sync(eaaCtx)
+		Expect(recorded()).To(Equal([]string{"POST /subscriptions/ns1"}))
+
+		Expect(removeSubscriptionToNamespace(consCN, "ns1", event,
+			eaaCtx)).To(Succeed())
+		peer.sync(eaaCtx)
+		Expect(recorded()).To(Equal([]string{"POST /subscriptions/ns1",
+			"DELETE /subscriptions/ns1"}))
+	})
+
+	g.It("delivers notifications of the peer to local consumers only", func() {
+		Expect(addSubscriptionToNamespace(consCN, "ns1", event,
+			eaaCtx)).To(Succeed())
+		Expect(addSubscriptionToNamespace(otherPeerCN, "ns1", event,
+			eaaCtx)).To(Succeed())
+		peer.sync(eaaCtx)
+
+		Eventually(func() *websocket.Conn {
+			lock.Lock()
+			defer lock.Unlock()
+			return peerConn
+		}).ShouldNot(BeNil())
+		Expect(peerConn.WriteJSON(NotificationToConsumer{
+			Name:    "event",
+			Version: "1.0.0",
+			Payload: json.RawMessage(`{"msg":"hello"}`),
+			URN:     URN{Namespace: "ns1", ID: "remote"},
+		})).To(Succeed())
+
+		Eventually(func() int {
+			return len(eaaCtx.notificationBuffer.since(consCN, 0))
+		}).Should(Equal(1))
+		var notif NotificationToConsumer
+		Expect(json.Unmarshal(eaaCtx.notificationBuffer.since(consCN, 0)[0],
+			&notif)).To(Succeed())
+		Expect(notif.URN.String()).To(Equal("ns1:remote"))
+		Expect(notif.Payload).To(MatchJSON(`{"msg":"hello"}`))
+		Expect(eaaCtx.notificationBuffer.since(otherPeerCN, 0)).To(BeEmpty())
+	})
+
+	g.It("connects to notifications of an EAA peer", func() {
+		peerCtx := &Context{}
+		peerCtx.serviceInfo = services{m: map[string]Service{
+			"ns1:remote": {URN: &URN{Namespace: "ns1", ID: "remote"},
+				Notifications: event}}}
+		peerCtx.consumerConnections = consumerConns{
+			m: make(map[string]ConsumerConnection)}
+		peerCtx.subscriptionInfo = NotificationSubscriptions{
+			m: make(map[UniqueNotif]*ConsumerSubscription)}
+		peerCtx.MsgBrokerCtx = NewGoChannelMsgBroker(peerCtx)
+
+		eaaServer := httptest.NewUnstartedServer(NewEaaRouter(peerCtx))
+		eaaServer.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+		eaaServer.StartTLS()
+		defer eaaServer.Close()
+
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).ShouldNot(HaveOccurred())
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: federateCN},
+			NotBefore:    time.Now().Add(-time.Minute),
+			NotAfter:     time.Now().Add(time.Hour),
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, template,
+			key.Public(), key)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		certPool := x509.NewCertPool()
+		certPool.AddCert(eaaServer.Certificate())
+		eaaPeer := newFederationPeer("node-2",
+			eaaServer.Listener.Addr().String(), federateCN, &tls.Config{
+				RootCAs: certPool,
+				Certificates: []tls.Certificate{{Certificate: [][]byte{der},
+					PrivateKey: key}},
+			})
+		defer eaaPeer.close()
+		eaaPeer.sync(eaaCtx)
+
+		f := &federation{peers: []*federationPeer{eaaPeer}}
+		Expect(f.services()).To(HaveLen(1))
+		eaaPeer.Lock()
+		conn := eaaPeer.conn
+		eaaPeer.Unlock()
+		Expect(conn).ToNot(BeNil())
+		Eventually(func() bool {
+			peerCtx.consumerConnections.RLock()
+			defer peerCtx.consumerConnections.RUnlock()
+			return peerCtx.consumerConnections.m[federateCN].connection != nil
+		}).Should(BeTrue())
+	})
+})
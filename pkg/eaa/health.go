@@ -0,0 +1,116 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package eaa
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	"github.com/open-ness/edgenode/pkg/auth"
+)
+
+const healthCheckOK = "ok"
+
+// HealthStatus is the body of the readiness probe response
+type HealthStatus struct {
+	Ready  bool              `json:"ready"`
+	Checks map[string]string `json:"checks"`
+}
+
+// NewHealthRouter initializes router serving liveness and readiness
+// probes on the open (non-TLS) endpoint
+func NewHealthRouter(eaaCtx *Context) *mux.Router {
+	router := mux.NewRouter().StrictSlash(true)
+	router.Methods(http.MethodGet).Path("/healthz").Name("Liveness").
+		HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})
+	router.Methods(http.MethodGet).Path("/readyz").Name("Readiness").
+		HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handleReadiness(w, eaaCtx)
+		})
+	return router
+}
+
+func handleReadiness(w http.ResponseWriter, eaaCtx *Context) {
+	status := checkReadiness(eaaCtx)
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	if status.Ready {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		log.Errf("Readiness response encoding failed: %#v", err)
+	}
+}
+
+// checkReadiness verifies that EAA is serving and its certificates
+// are valid
+func checkReadiness(eaaCtx *Context) HealthStatus {
+	status := HealthStatus{Ready: true, Checks: make(map[string]string)}
+	set := func(name string, err error) {
+		if err != nil {
+			status.Ready = false
+			status.Checks[name] = err.Error()
+			return
+		}
+		status.Checks[name] = healthCheckOK
+	}
+
+	var err error
+	if atomic.LoadInt32(&eaaCtx.serving) == 0 {
+		err = errors.New("TLS endpoint is not serving")
+	}
+	set("server", err)
+
+	err = nil
+	switch {
+	case eaaCtx.serverCert != nil:
+		// The rotated certificate if any
+		err = validateCert(eaaCtx.serverCert.certificate())
+	case eaaCtx.certsEaaCa.eaa == nil:
+		err = errors.New("EAA certificate not loaded")
+	default:
+		err = validateCert(eaaCtx.certsEaaCa.eaa.x509Cert)
+	}
+	set("serverCertificate", err)
+
+	rootCA, err := auth.LoadCert(eaaCtx.cfg.Certs.CaRootPath)
+	if err == nil {
+		err = validateCert(rootCA)
+	}
+	set("rootCACertificate", err)
+
+	return status
+}
+
+// runHealthServer serves the health probes on the open endpoint until
+// parentCtx is done. Failure to serve is logged and does not stop EAA.
+func runHealthServer(parentCtx context.Context, eaaCtx *Context) {
+	server := &http.Server{
+		Addr:    eaaCtx.cfg.OpenEndpoint,
+		Handler: NewHealthRouter(eaaCtx),
+	}
+
+	go func() {
+		<-parentCtx.Done()
+		if err := server.Close(); err != nil {
+			log.Errf("Could not close health server: %#v", err)
+		}
+	}()
+
+	go func() {
+		log.Infof("Serving health probes on: %s", eaaCtx.cfg.OpenEndpoint)
+		if err := server.ListenAndServe(); err != http.ErrServerClosed {
+			log.Errf("Health server error: %#v", err)
+		}
+	}()
+}
@@ -0,0 +1,79 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package eaa_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/open-ness/edgenode/pkg/eaa"
+)
+
+var _ = Describe("Health", func() {
+	startStopCh := make(chan bool)
+
+	getProbe := func(path string) *http.Response {
+		var (
+			resp *http.Response
+			err  error
+		)
+		Eventually(func() error {
+			resp, err = http.Get("http://" + cfg.OpenEndpoint + path)
+			return err
+		}, 2*time.Second, 100*time.Millisecond).Should(Succeed())
+		return resp
+	}
+
+	Context("EAA is running", func() {
+		BeforeEach(func() {
+			err := runEaa(startStopCh)
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			stopEaa(startStopCh)
+		})
+
+		It("Reports liveness", func() {
+			resp := getProbe("/healthz")
+			defer resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		})
+
+		It("Reports readiness with all checks passing", func() {
+			resp := getProbe("/readyz")
+			defer resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+			var status eaa.HealthStatus
+			Expect(json.NewDecoder(resp.Body).Decode(&status)).To(Succeed())
+			Expect(status.Ready).To(BeTrue())
+			Expect(status.Checks).To(HaveLen(3))
+			for name, result := range status.Checks {
+				Expect(result).To(Equal("ok"), name)
+			}
+		})
+	})
+
+	Context("EAA is stopped", func() {
+		It("Stops serving health probes", func() {
+			Expect(runEaa(startStopCh)).ShouldNot(HaveOccurred())
+			resp := getProbe("/healthz")
+			resp.Body.Close()
+			stopEaa(startStopCh)
+
+			Eventually(func() error {
+				resp, err := http.Get("http://" + cfg.OpenEndpoint + "/healthz")
+				if err == nil {
+					resp.Body.Close()
+				}
+				return err
+			}, 2*time.Second, 100*time.Millisecond).Should(HaveOccurred())
+		})
+	})
+})
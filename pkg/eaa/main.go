@@ -13,12 +13,14 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+	"sync/atomic"
 
 	"github.com/google/uuid"
 	logger "github.com/open-ness/common/log"
 	"github.com/open-ness/edgenode/pkg/config"
 	"github.com/open-ness/edgenode/pkg/util"
 	"github.com/pkg/errors"
+	"google.golang.org/grpc"
 )
 
 type services struct {
@@ -39,6 +41,21 @@ type Context struct {
 	certsEaaCa          Certs
 	cfg                 Config
 	MsgBrokerCtx        msgBroker
+	notificationBuffer  *notificationStore
+	state               *stateStore
+	liveness            *serviceLiveness
+	limiter             *clientLimiter
+	revocation          *revocationList
+	serverCert          *serverCertificate
+	federation          *federation
+	deliveryStats       *deliveryStats
+	audit               *auditLog
+	tokenAuth           *tokenAuthenticator
+	serving             int32
+	ready               chan struct{}
+	startErr            error
+	tlsAddr             string
+	grpcAddr            string
 }
 
 // Certs stores certs and keys for root ca and eaa
@@ -91,25 +108,118 @@ func newKafkaTLSConfig(clientCertFile, clientKeyFile, caCertFile string) (*tls.C
 	return &tlsConfig, nil
 }
 
+// Ready returns a channel closed when RunServer starts serving the API or
+// fails before that, StartErr tells which. Once serving, TLSAddr and
+// GrpcAddr return the actual addresses.
+func (c *Context) Ready() <-chan struct{} {
+	return c.ready
+}
+
+// StartErr returns the error RunServer failed with before serving the API,
+// it's valid once Ready is closed
+func (c *Context) StartErr() error {
+	return c.startErr
+}
+
+// signalReady records the outcome of starting to serve and closes ready
+func (c *Context) signalReady(err error) {
+	if c.ready == nil {
+		return
+	}
+	c.startErr = err
+	close(c.ready)
+}
+
+// TLSAddr returns the address the TLS API is served on, which differs
+// from TlsEndpoint when it has an ephemeral port
+func (c *Context) TLSAddr() string {
+	return c.tlsAddr
+}
+
+// GrpcAddr returns the address the gRPC API is served on, empty if the
+// gRPC API is disabled
+func (c *Context) GrpcAddr() string {
+	return c.grpcAddr
+}
+
 // InitEaaContext initializes the Eaa Context
 func InitEaaContext(cfgPath string, eaaCtx *Context) error {
+	var cfg Config
+	if err := config.LoadConfig(cfgPath, "EAA", &cfg); err != nil {
+		log.Errf("Failed to load config: %#v", err)
+		return err
+	}
+	return InitEaaContextFromConfig(cfg, eaaCtx)
+}
+
+// InitEaaContextFromConfig initializes the Eaa Context with the config
+// instead of a config file, e.g. to run EAA embedded in another process or
+// a test with "localhost:0" endpoints
+func InitEaaContextFromConfig(cfg Config, eaaCtx *Context) error {
 	eaaCtx.serviceInfo = services{m: make(map[string]Service)}
 	eaaCtx.consumerConnections = consumerConns{m: make(map[string]ConsumerConnection)}
 	eaaCtx.subscriptionInfo = NotificationSubscriptions{
 		m: make(map[UniqueNotif]*ConsumerSubscription)}
+	eaaCtx.cfg = cfg
+	eaaCtx.ready = make(chan struct{})
 
 	var err error
 
-	err = config.LoadJSONConfig(cfgPath, &eaaCtx.cfg)
-	if err != nil {
-		log.Errf("Failed to load config: %#v", err)
+	if err = eaaCtx.cfg.Validate(); err != nil {
+		log.Errf("Config validation failed: %v", err)
 		return err
 	}
 
+	eaaCtx.notificationBuffer = newNotificationStore(eaaCtx.cfg.NotificationBuffer)
+	if eaaCtx.cfg.Delivery.MaxRetries > 0 {
+		eaaCtx.notificationBuffer.sequenced = true
+		eaaCtx.deliveryStats = newDeliveryStats()
+	}
+	if eaaCtx.cfg.StatePath != "" {
+		eaaCtx.state = newStateStore(eaaCtx.cfg.StatePath)
+	}
+	if eaaCtx.cfg.ServiceLiveness.TTL.Duration > 0 {
+		eaaCtx.liveness = newServiceLiveness(eaaCtx.cfg.ServiceLiveness)
+	}
+	if eaaCtx.cfg.RateLimit != (RateLimitConfig{}) {
+		eaaCtx.limiter = newClientLimiter(eaaCtx.cfg.RateLimit)
+	}
+	if eaaCtx.cfg.Certs.CRLPath != "" {
+		eaaCtx.revocation, err = newRevocationList(eaaCtx.cfg.Certs.CRLPath,
+			eaaCtx.cfg.Certs.CaRootPath)
+		if err != nil {
+			log.Errf("Failed to load CRL: %v", err)
+			return err
+		}
+	}
+
 	if eaaCtx.certsEaaCa.eaa, err = InitEaaCert(eaaCtx.cfg.Certs); err != nil {
 		log.Errf("EAA cert creation error: %#v", err)
 		return err
 	}
+	eaaCtx.serverCert, err = newServerCertificate(
+		eaaCtx.cfg.Certs.ServerCertPath, eaaCtx.cfg.Certs.ServerKeyPath)
+	if err != nil {
+		log.Errf("EAA server certificate error: %v", err)
+		return err
+	}
+	if eaaCtx.cfg.Audit.Path != "" {
+		if eaaCtx.audit, err = newAuditLog(eaaCtx.cfg.Audit); err != nil {
+			log.Errf("EAA audit log error: %v", err)
+			return err
+		}
+	}
+	if eaaCtx.cfg.TokenAuth.JWKSURL != "" {
+		eaaCtx.tokenAuth = newTokenAuthenticator(eaaCtx.cfg.TokenAuth)
+	}
+	if len(eaaCtx.cfg.Federation.Peers) > 0 {
+		eaaCtx.federation, err = newFederation(eaaCtx.cfg.Federation,
+			eaaCtx.cfg.Certs.CaRootPath, eaaCtx.cfg.Certs.CommonName)
+		if err != nil {
+			log.Errf("EAA federation error: %v", err)
+			return err
+		}
+	}
 
 	return nil
 }
@@ -128,16 +238,20 @@ func RunServer(parentCtx context.Context, eaaCtx *Context) error {
 	server := &http.Server{
 		Addr: eaaCtx.cfg.TLSEndpoint,
 		TLSConfig: &tls.Config{
-			ClientAuth:   tls.RequireAndVerifyClientCert,
-			ClientCAs:    certPool,
-			MinVersion:   tls.VersionTLS12,
-			CipherSuites: []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256},
+			ClientAuth:            eaaCtx.tokenAuth.clientAuth(),
+			ClientCAs:             certPool,
+			MinVersion:            tls.VersionTLS12,
+			CipherSuites:          []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256},
+			VerifyPeerCertificate: eaaCtx.revocation.verifyPeerCertificate,
+			GetCertificate:        eaaCtx.serverCert.getCertificate,
 		},
 		Handler: router,
 	}
 
 	stopServerCh := make(chan bool, 2)
 	var lis net.Listener
+	var grpcServer *grpc.Server
+	started := false
 
 	// Add Publisher and Subscriber for Services topic
 	err = eaaCtx.MsgBrokerCtx.addPublisher(servicesPublisher, servicesTopic, nil)
@@ -153,6 +267,12 @@ func RunServer(parentCtx context.Context, eaaCtx *Context) error {
 		goto cleanup
 	}
 
+	// Restore services and subscriptions registered before a restart, EAA
+	// still works without them, so the error is not fatal
+	if restoreErr := restoreState(eaaCtx); restoreErr != nil {
+		log.Errf("Failed to restore EAA state: %v", restoreErr)
+	}
+
 	lis, err = net.Listen("tcp", eaaCtx.cfg.TLSEndpoint)
 	if err != nil {
 
@@ -164,26 +284,53 @@ func RunServer(parentCtx context.Context, eaaCtx *Context) error {
 		}
 		goto cleanup
 	}
+	eaaCtx.tlsAddr = lis.Addr().String()
+
+	if eaaCtx.cfg.GrpcEndpoint != "" {
+		if grpcServer, err = runGrpcServer(eaaCtx, certPool); err != nil {
+			log.Errf("Failed to start gRPC server: %+v", err)
+			if closeErr := lis.Close(); closeErr != nil {
+				log.Errf("Failed to close listener: %v", closeErr)
+			}
+			goto cleanup
+		}
+	}
+
+	if eaaCtx.cfg.OpenEndpoint != "" {
+		runHealthServer(parentCtx, eaaCtx)
+	}
+
+	go eaaCtx.liveness.watch(parentCtx, eaaCtx)
+	go eaaCtx.federation.run(parentCtx, eaaCtx)
 
 	go func(stopServerCh chan bool) {
 		<-parentCtx.Done()
+		atomic.StoreInt32(&eaaCtx.serving, 0)
 		log.Info("Executing graceful stop")
 		if servErr := server.Close(); servErr != nil {
 			log.Errf("Could not close EAA server: %#v", servErr)
 		}
+		if grpcServer != nil {
+			// Notification streams never end on their own, so there is
+			// nothing to wait for with a graceful stop
+			grpcServer.Stop()
+		}
 		log.Info("EAA server stopped")
 		stopServerCh <- true
 	}(stopServerCh)
 
 	defer log.Info("Stopped EAA serving")
 
-	log.Infof("Serving EAA on: %s", eaaCtx.cfg.TLSEndpoint)
+	log.Infof("Serving EAA on: %s", eaaCtx.tlsAddr)
 	util.Heartbeat(parentCtx, eaaCtx.cfg.HeartbeatInterval, func() {
 		// TODO: implementation of modules checking
 		log.Info("Heartbeat")
 	})
-	if err = server.ServeTLS(lis, eaaCtx.cfg.Certs.ServerCertPath,
-		eaaCtx.cfg.Certs.ServerKeyPath); err != http.ErrServerClosed {
+	atomic.StoreInt32(&eaaCtx.serving, 1)
+	started = true
+	eaaCtx.signalReady(nil)
+	// The certificate is served by GetCertificate to allow its rotation
+	if err = server.ServeTLS(lis, "", ""); err != http.ErrServerClosed {
 		log.Errf("server.Serve error: %#v", err)
 		goto cleanup
 	} else {
@@ -192,6 +339,15 @@ func RunServer(parentCtx context.Context, eaaCtx *Context) error {
 	<-stopServerCh
 
 cleanup:
+	if !started {
+		eaaCtx.signalReady(err)
+	}
+	// Stop is a no-op if the server was already stopped on cancellation,
+	// but it keeps the gRPC server from outliving a failed TLS server
+	if grpcServer != nil {
+		grpcServer.Stop()
+	}
+	eaaCtx.audit.close()
 	cleanupErr := eaaCtx.MsgBrokerCtx.removeAll()
 	if cleanupErr != nil {
 		if err == nil {
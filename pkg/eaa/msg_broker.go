@@ -149,6 +149,9 @@ func handleServiceUpdates(messages <-chan *message.Message, eaaCtx *Context) {
 		default:
 			log.Errf("Unknown Service Action: %v", svcMsg.Action)
 		}
+		eaaCtx.state.serviceUpdated(commonName, &svcMsg)
+		eaaCtx.liveness.serviceUpdated(commonName, &svcMsg, eaaCtx)
+		eaaCtx.state.save(eaaCtx)
 
 		// we need to Acknowledge that we received and processed the message,
 		// otherwise, it will be resent over and over again.
@@ -203,6 +206,7 @@ func handleClientUpdates(messages <-chan *message.Message, eaaCtx *Context) {
 		default:
 			log.Errf("Unknown SubscriptionMessage Action: %v", subscriptionMsg.Action)
 		}
+		eaaCtx.state.save(eaaCtx)
 
 		msg.Ack()
 	}
@@ -0,0 +1,153 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package eaa
+
+import (
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Policies applied when the send queue of a consumer connection is full,
+// see NotificationLimitsConfig
+const (
+	queueFullPolicyDrop       = "drop"
+	queueFullPolicyDisconnect = "disconnect"
+)
+
+// defaultSendQueueSize is the size of the send queue of a connection that
+// is always queued when SendQueueSize isn't set
+const defaultSendQueueSize = 64
+
+// queuedNotification is a notification waiting in the send queue of
+// a websocket connection
+type queuedNotification struct {
+	seq     uint64
+	payload []byte
+}
+
+// checkPayloadSize checks the notification payload against MaxPayloadSize
+func checkPayloadSize(notif *NotificationFromProducer, eaaCtx *Context) error {
+	maxSize := eaaCtx.cfg.NotificationLimits.MaxPayloadSize
+	if maxSize > 0 && len(notif.Payload) > maxSize {
+		return errors.Errorf("payload of %d bytes exceeds the limit of %d",
+			len(notif.Payload), maxSize)
+	}
+	return nil
+}
+
+// queueWsNotification queues the notification for sending over the
+// websocket connection, see applyQueueFullPolicy when the queue is full
+func queueWsNotification(c ConsumerConnection, commonName string, seq uint64,
+	payload []byte, eaaCtx *Context) error {
+
+	select {
+	case c.queue <- queuedNotification{seq: seq, payload: payload}:
+		return nil
+	default:
+		return applyQueueFullPolicy(c, commonName, eaaCtx)
+	}
+}
+
+// queueStreamNotification queues the notification for sending over the gRPC
+// stream, see applyQueueFullPolicy when the queue is full
+func queueStreamNotification(c ConsumerConnection, commonName string,
+	payload []byte, eaaCtx *Context) error {
+
+	select {
+	case c.notifications <- payload:
+		return nil
+	default:
+		return applyQueueFullPolicy(c, commonName, eaaCtx)
+	}
+}
+
+// sendQueueSize returns the size of the send queue of a connection. gRPC
+// streams and websocket connections replaying notifications are always
+// queued, other websocket connections only if SendQueueSize is set.
+func sendQueueSize(eaaCtx *Context) int {
+	if size := eaaCtx.cfg.NotificationLimits.SendQueueSize; size > 0 {
+		return size
+	}
+	return defaultSendQueueSize
+}
+
+// applyQueueFullPolicy is called when the send queue of the consumer
+// connection is full. The notification is dropped and with the disconnect
+// policy the connection is closed as well, the consumer may then reconnect
+// and replay missed notifications.
+func applyQueueFullPolicy(c ConsumerConnection, commonName string,
+	eaaCtx *Context) error {
+
+	if eaaCtx.cfg.NotificationLimits.QueueFullPolicy == queueFullPolicyDisconnect {
+		log.Warningf("Send queue of %s is full, disconnecting", commonName)
+		if c.closeStream != nil {
+			c.closeStream(status.Error(codes.ResourceExhausted,
+				"Send queue is full"))
+			return errors.New("send queue of the consumer connection is full")
+		}
+
+		err := c.connection.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseTryAgainLater,
+				"Send queue is full"), time.Now().Add(controlWriteTimeout))
+		if err != nil {
+			log.Debugf("Failed to send close message to %s: %v", commonName,
+				err)
+		}
+		if err = c.connection.Close(); err != nil {
+			log.Debugf("Failed to close websocket of %s: %v", commonName, err)
+		}
+	}
+	return errors.New("send queue of the consumer connection is full")
+}
+
+// sendQueuedNotifications sends replayed notifications and then those
+// queued for the websocket connection until done is closed
+func sendQueuedNotifications(conn *websocket.Conn, replayed [][]byte,
+	queue <-chan queuedNotification, acks *pendingDeliveries,
+	commonName string, done <-chan struct{}, eaaCtx *Context) {
+
+	for _, payload := range replayed {
+		if err := writeNotification(conn, payload); err != nil {
+			log.Errf("Failed to replay notifications to %s: %v", commonName,
+				err)
+			break
+		}
+	}
+
+	for {
+		select {
+		case <-done:
+			return
+		case notif := <-queue:
+			var err error
+			if acks != nil {
+				err = conn.SetWriteDeadline(
+					time.Now().Add(notificationWriteTimeout))
+				if err == nil {
+					err = acks.send(conn, notif.seq, notif.payload, eaaCtx)
+				}
+			} else {
+				err = writeNotification(conn, notif.payload)
+			}
+			if err != nil {
+				log.Debugf("Failed to send notification to %s: %v",
+					commonName, err)
+			}
+		}
+	}
+}
+
+// writeNotification sends the notification payload over the websocket
+// connection within notificationWriteTimeout
+func writeNotification(conn *websocket.Conn, payload []byte) error {
+	err := conn.SetWriteDeadline(time.Now().Add(notificationWriteTimeout))
+	if err != nil {
+		return err
+	}
+	return conn.WriteMessage(websocket.TextMessage, payload)
+}
@@ -0,0 +1,185 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package eaa
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/gorilla/websocket"
+	g "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var _ = g.Describe("notification limits", func() {
+	const consumer = "ns:consumer"
+
+	var (
+		eaaCtx   *Context
+		server   *httptest.Server
+		client   *websocket.Conn
+		received chan NotificationToConsumer
+		closed   chan error
+	)
+
+	// connect connects the consumer with the send queue, the queued
+	// notifications are sent only if watch is set
+	connect := func(watch bool) {
+		connected := make(chan struct{})
+		server = httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				conn, err := socket.Upgrade(w, r, nil)
+				if err != nil {
+					return
+				}
+				eaaCtx.consumerConnections.Lock()
+				eaaCtx.consumerConnections.m[consumer] = ConsumerConnection{
+					connection: conn,
+					queue: make(chan queuedNotification,
+						eaaCtx.cfg.NotificationLimits.SendQueueSize),
+				}
+				eaaCtx.consumerConnections.Unlock()
+				if watch {
+					go watchWsConn(conn, consumer, nil, eaaCtx)
+				}
+				close(connected)
+			}))
+
+		var err error
+		client, _, err = websocket.DefaultDialer.Dial(
+			"ws"+strings.TrimPrefix(server.URL, "http"), nil)
+		Expect(err).ShouldNot(HaveOccurred())
+		Eventually(connected).Should(BeClosed())
+
+		// The reader keeps its own references, the variables are reset by
+		// the next spec
+		conn, notifs, errs := client, make(chan NotificationToConsumer, 10),
+			make(chan error, 1)
+		received, closed = notifs, errs
+		go func() {
+			for {
+				var notif NotificationToConsumer
+				if err := conn.ReadJSON(&notif); err != nil {
+					errs <- err
+					return
+				}
+				notifs <- notif
+			}
+		}()
+	}
+
+	send := func(seq uint64) error {
+		payload, err := json.Marshal(NotificationToConsumer{
+			Name:     "event",
+			Version:  "1.0.0",
+			URN:      URN{Namespace: "ns", ID: "producer"},
+			Sequence: seq,
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+		return sendNotificationToSubscriber(consumer, seq, payload, eaaCtx)
+	}
+
+	g.BeforeEach(func() {
+		client, server = nil, nil
+		eaaCtx = &Context{}
+		eaaCtx.consumerConnections = consumerConns{m: make(map[string]ConsumerConnection)}
+		eaaCtx.cfg.NotificationLimits = NotificationLimitsConfig{
+			MaxPayloadSize: 16,
+			SendQueueSize:  2,
+		}
+	})
+
+	g.AfterEach(func() {
+		if client != nil {
+			client.Close()
+			server.Close()
+		}
+	})
+
+	g.It("sends queued notifications", func() {
+		connect(true)
+
+		Expect(send(1)).To(Succeed())
+		Expect(send(2)).To(Succeed())
+
+		var notif NotificationToConsumer
+		Eventually(received).Should(Receive(&notif))
+		Expect(notif.Sequence).To(BeEquivalentTo(1))
+		Eventually(received).Should(Receive(&notif))
+		Expect(notif.Sequence).To(BeEquivalentTo(2))
+	})
+
+	g.It("drops notifications when the queue is full", func() {
+		connect(false)
+
+		Expect(send(1)).To(Succeed())
+		Expect(send(2)).To(Succeed())
+		Expect(send(3)).ToNot(Succeed())
+		Consistently(closed).ShouldNot(Receive())
+	})
+
+	g.It("disconnects the consumer when the queue is full", func() {
+		eaaCtx.cfg.NotificationLimits.QueueFullPolicy = queueFullPolicyDisconnect
+		connect(false)
+
+		Expect(send(1)).To(Succeed())
+		Expect(send(2)).To(Succeed())
+		Expect(send(3)).ToNot(Succeed())
+
+		var err error
+		Eventually(closed).Should(Receive(&err))
+		Expect(websocket.IsCloseError(err, websocket.CloseTryAgainLater)).To(
+			BeTrue())
+	})
+
+	g.Describe("over a gRPC stream", func() {
+		var closed chan error
+
+		g.BeforeEach(func() {
+			closed = make(chan error, 1)
+			eaaCtx.consumerConnections.m[consumer] = ConsumerConnection{
+				notifications: make(chan []byte, sendQueueSize(eaaCtx)),
+				closeStream:   func(reason error) { closed <- reason },
+			}
+		})
+
+		g.It("queues up to the send queue size", func() {
+			Expect(sendQueueSize(eaaCtx)).To(Equal(2))
+			eaaCtx.cfg.NotificationLimits.SendQueueSize = 0
+			Expect(sendQueueSize(eaaCtx)).To(Equal(defaultSendQueueSize))
+		})
+
+		g.It("drops notifications when the queue is full", func() {
+			Expect(send(1)).To(Succeed())
+			Expect(send(2)).To(Succeed())
+			Expect(send(3)).ToNot(Succeed())
+			Expect(closed).ToNot(Receive())
+		})
+
+		g.It("disconnects the consumer when the queue is full", func() {
+			eaaCtx.cfg.NotificationLimits.QueueFullPolicy =
+				queueFullPolicyDisconnect
+
+			Expect(send(1)).To(Succeed())
+			Expect(send(2)).To(Succeed())
+			Expect(send(3)).ToNot(Succeed())
+
+			var err error
+			Expect(closed).To(Receive(&err))
+			Expect(status.Code(err)).To(Equal(codes.ResourceExhausted))
+		})
+	})
+
+	g.It("limits the payload size", func() {
+		Expect(checkPayloadSize(&NotificationFromProducer{
+			Payload: json.RawMessage(`{"msg":"hello"}`)}, eaaCtx)).To(Succeed())
+		Expect(checkPayloadSize(&NotificationFromProducer{
+			Payload: json.RawMessage(`{"msg":"hello world"}`)},
+			eaaCtx)).ToNot(Succeed())
+	})
+})
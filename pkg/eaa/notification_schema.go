@@ -0,0 +1,255 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package eaa
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// jsonSchema is the subset of JSON Schema supported for validation of
+// notification payloads. Unsupported keywords are ignored as JSON Schema
+// requires for unknown keywords.
+type jsonSchema struct {
+	Type                 jsonSchemaTypes        `json:"type"`
+	Enum                 []interface{}          `json:"enum"`
+	Properties           map[string]*jsonSchema `json:"properties"`
+	Required             []string               `json:"required"`
+	AdditionalProperties *bool                  `json:"additionalProperties"`
+	Items                *jsonSchema            `json:"items"`
+	Minimum              *float64               `json:"minimum"`
+	Maximum              *float64               `json:"maximum"`
+	MinLength            *int                   `json:"minLength"`
+	MaxLength            *int                   `json:"maxLength"`
+	MinItems             *int                   `json:"minItems"`
+	MaxItems             *int                   `json:"maxItems"`
+}
+
+// jsonSchemaTypes is the "type" keyword, a single type or a list of them
+type jsonSchemaTypes []string
+
+func (t *jsonSchemaTypes) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = jsonSchemaTypes{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return errors.New("type must be a string or an array of strings")
+	}
+	*t = list
+	return nil
+}
+
+var jsonSchemaKnownTypes = map[string]bool{
+	"null": true, "boolean": true, "object": true, "array": true,
+	"number": true, "string": true, "integer": true,
+}
+
+// parseJSONSchema parses and checks the schema
+func parseJSONSchema(data []byte) (*jsonSchema, error) {
+	var schema jsonSchema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, errors.Wrap(err, "invalid JSON schema")
+	}
+	if err := schema.check(); err != nil {
+		return nil, errors.Wrap(err, "invalid JSON schema")
+	}
+	return &schema, nil
+}
+
+func (s *jsonSchema) check() error {
+	for _, t := range s.Type {
+		if !jsonSchemaKnownTypes[t] {
+			return errors.Errorf("unknown type %q", t)
+		}
+	}
+	for name, prop := range s.Properties {
+		if prop == nil {
+			return errors.Errorf("property %q has no schema", name)
+		}
+		if err := prop.check(); err != nil {
+			return errors.Wrapf(err, "property %q", name)
+		}
+	}
+	if s.Items != nil {
+		if err := s.Items.check(); err != nil {
+			return errors.Wrap(err, "items")
+		}
+	}
+	return nil
+}
+
+// validate checks the JSON document against the schema
+func (s *jsonSchema) validate(data []byte) error {
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return errors.Wrap(err, "payload is not valid JSON")
+	}
+	return s.validateValue("payload", value)
+}
+
+func (s *jsonSchema) validateValue(path string, value interface{}) error {
+	if len(s.Type) != 0 {
+		matched := false
+		for _, t := range s.Type {
+			if jsonTypeMatches(t, value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return errors.Errorf("%s: expected %s", path,
+				strings.Join(s.Type, " or "))
+		}
+	}
+
+	if len(s.Enum) != 0 && !jsonEnumContains(s.Enum, value) {
+		return errors.Errorf("%s: value is not one of the allowed values", path)
+	}
+
+	switch v := value.(type) {
+	case json.Number:
+		n, _ := v.Float64()
+		if s.Minimum != nil && n < *s.Minimum {
+			return errors.Errorf("%s: must be at least %v", path, *s.Minimum)
+		}
+		if s.Maximum != nil && n > *s.Maximum {
+			return errors.Errorf("%s: must be at most %v", path, *s.Maximum)
+		}
+	case string:
+		length := len([]rune(v))
+		if s.MinLength != nil && length < *s.MinLength {
+			return errors.Errorf("%s: must be at least %d characters long",
+				path, *s.MinLength)
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			return errors.Errorf("%s: must be at most %d characters long",
+				path, *s.MaxLength)
+		}
+	case []interface{}:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			return errors.Errorf("%s: must have at least %d items", path,
+				*s.MinItems)
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			return errors.Errorf("%s: must have at most %d items", path,
+				*s.MaxItems)
+		}
+		if s.Items != nil {
+			for i, item := range v {
+				err := s.Items.validateValue(fmt.Sprintf("%s[%d]", path, i),
+					item)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, found := v[name]; !found {
+				return errors.Errorf("%s: missing required property %q",
+					path, name)
+			}
+		}
+		for name, prop := range v {
+			propSchema, found := s.Properties[name]
+			if !found {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					return errors.Errorf("%s: unexpected property %q", path,
+						name)
+				}
+				continue
+			}
+			if err := propSchema.validateValue(path+"."+name, prop); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func jsonTypeMatches(t string, value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return t == "null"
+	case bool:
+		return t == "boolean"
+	case string:
+		return t == "string"
+	case []interface{}:
+		return t == "array"
+	case map[string]interface{}:
+		return t == "object"
+	case json.Number:
+		if t == "number" {
+			return true
+		}
+		if t == "integer" {
+			n, err := v.Float64()
+			return err == nil && n == float64(int64(n))
+		}
+	}
+	return false
+}
+
+func jsonEnumContains(enum []interface{}, value interface{}) bool {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return false
+	}
+	for _, allowed := range enum {
+		allowedEncoded, err := json.Marshal(allowed)
+		if err == nil && bytes.Equal(allowedEncoded, encoded) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkNotificationSchemas checks schemas of notifications of the service
+// being registered
+func checkNotificationSchemas(serv *Service) error {
+	for _, notif := range serv.Notifications {
+		if len(notif.Schema) == 0 {
+			continue
+		}
+		if _, err := parseJSONSchema(notif.Schema); err != nil {
+			return errors.Wrapf(err, "notification %s %s", notif.Name,
+				notif.Version)
+		}
+	}
+	return nil
+}
+
+// validateNotificationPayload checks the payload of the notification
+// against the schema registered by the producer for its name and version.
+// Notifications without a registered schema are not checked.
+func validateNotificationPayload(serv *Service,
+	notif *NotificationFromProducer) error {
+	for _, desc := range serv.Notifications {
+		if desc.Name != notif.Name || desc.Version != notif.Version ||
+			len(desc.Schema) == 0 {
+			continue
+		}
+
+		schema, err := parseJSONSchema(desc.Schema)
+		if err != nil {
+			return err
+		}
+		payload := []byte(notif.Payload)
+		if len(payload) == 0 {
+			payload = []byte("null")
+		}
+		return schema.validate(payload)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package eaa
+
+import (
+	"encoding/json"
+
+	g "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = g.Describe("jsonSchema", func() {
+	const schemaJSON = `{
+		"type": "object",
+		"required": ["id", "level"],
+		"additionalProperties": false,
+		"properties": {
+			"id": {"type": "integer", "minimum": 1},
+			"level": {"enum": ["info", "alarm"]},
+			"tags": {
+				"type": "array",
+				"maxItems": 2,
+				"items": {"type": "string", "minLength": 1}
+			},
+			"note": {"type": ["string", "null"]}
+		}
+	}`
+
+	var schema *jsonSchema
+
+	g.BeforeEach(func() {
+		var err error
+		schema, err = parseJSONSchema([]byte(schemaJSON))
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	g.It("accepts valid payloads", func() {
+		Expect(schema.validate([]byte(`{"id": 1, "level": "alarm"}`))).
+			To(Succeed())
+		Expect(schema.validate([]byte(
+			`{"id": 2, "level": "info", "tags": ["a", "b"], "note": null}`))).
+			To(Succeed())
+	})
+
+	g.It("rejects invalid payloads with the reason", func() {
+		for payload, reason := range map[string]string{
+			`[]`:                                 "payload: expected object",
+			`{"id": 1}`:                          `missing required property "level"`,
+			`{"id": 1, "level": "info", "x": 1}`: `unexpected property "x"`,
+			`{"id": 1.5, "level": "info"}`:       "payload.id: expected integer",
+			`{"id": 0, "level": "info"}`:         "payload.id: must be at least 1",
+			`{"id": 1, "level": "debug"}`: "payload.level: value is not " +
+				"one of the allowed values",
+			`{"id": 1, "level": "info", "tags": ["a", "b", "c"]}`: "payload.tags: " +
+				"must have at most 2",
+			`{"id": 1, "level": "info", "tags": [""]}`: "payload.tags[0]: " +
+				"must be at least 1 characters long",
+			`{`: "payload is not valid JSON",
+		} {
+			err := schema.validate([]byte(payload))
+			Expect(err).To(HaveOccurred(), payload)
+			Expect(err.Error()).To(ContainSubstring(reason))
+		}
+	})
+
+	g.It("rejects invalid schemas", func() {
+		_, err := parseJSONSchema([]byte(`{"type": "text"}`))
+		Expect(err).To(HaveOccurred())
+		_, err = parseJSONSchema([]byte(
+			`{"properties": {"a": {"type": 1}}}`))
+		Expect(err).To(HaveOccurred())
+	})
+
+	g.It("validates only notifications with a registered schema", func() {
+		serv := Service{Notifications: []NotificationDescriptor{
+			{Name: "alarm", Version: "1.0.0", Schema: json.RawMessage(schemaJSON)},
+			{Name: "event", Version: "1.0.0"},
+		}}
+
+		Expect(validateNotificationPayload(&serv, &NotificationFromProducer{
+			Name: "alarm", Version: "1.0.0", Payload: json.RawMessage(`{}`)})).
+			ToNot(Succeed())
+		Expect(validateNotificationPayload(&serv, &NotificationFromProducer{
+			Name: "alarm", Version: "2.0.0", Payload: json.RawMessage(`{}`)})).
+			To(Succeed())
+		Expect(validateNotificationPayload(&serv, &NotificationFromProducer{
+			Name: "event", Version: "1.0.0", Payload: json.RawMessage(`{}`)})).
+			To(Succeed())
+	})
+})
@@ -0,0 +1,119 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package eaa
+
+import (
+	"sync"
+	"time"
+)
+
+// bufferedNotification is a notification kept for replay to a consumer
+type bufferedNotification struct {
+	seq       uint64
+	timestamp time.Time
+	payload   []byte
+}
+
+// notificationStore keeps the most recent notifications of every consumer,
+// bounded by size and age, so that a consumer that reconnects can catch up
+// on notifications it missed. A nil or zero size store keeps nothing.
+type notificationStore struct {
+	sync.Mutex
+	size int
+	ttl  time.Duration
+	seq  uint64
+	m    map[string][]bufferedNotification
+	// sequenced makes the store number notifications even if it keeps
+	// nothing, acknowledgments of notifications refer to the numbers
+	sequenced bool
+}
+
+func newNotificationStore(cfg NotificationBufferConfig) *notificationStore {
+	return &notificationStore{
+		size: cfg.Size,
+		ttl:  cfg.TTL.Duration,
+		m:    make(map[string][]bufferedNotification),
+	}
+}
+
+func (s *notificationStore) enabled() bool {
+	return s != nil && s.size > 0
+}
+
+// nextSequence returns the sequence number of a new notification, 0 if the
+// store is disabled and not sequenced
+func (s *notificationStore) nextSequence() uint64 {
+	if !s.enabled() && (s == nil || !s.sequenced) {
+		return 0
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	s.seq++
+	return s.seq
+}
+
+// add stores the notification payload with sequence number seq for the
+// consumer subID dropping the oldest and expired ones
+func (s *notificationStore) add(subID string, seq uint64, payload []byte) {
+	if !s.enabled() || seq == 0 {
+		return
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	buf := append(s.unexpired(subID), bufferedNotification{
+		seq:       seq,
+		timestamp: time.Now(),
+		payload:   payload,
+	})
+	if len(buf) > s.size {
+		buf = buf[len(buf)-s.size:]
+	}
+	s.m[subID] = buf
+}
+
+// since returns payloads of the stored notifications of the consumer subID
+// with sequence number greater than seq, oldest first
+func (s *notificationStore) since(subID string, seq uint64) [][]byte {
+	if !s.enabled() {
+		return nil
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	buf := s.unexpired(subID)
+	if len(buf) == 0 {
+		delete(s.m, subID)
+		return nil
+	}
+	s.m[subID] = buf
+
+	var payloads [][]byte
+	for _, n := range buf {
+		if n.seq > seq {
+			payloads = append(payloads, n.payload)
+		}
+	}
+	return payloads
+}
+
+// unexpired returns stored notifications of the consumer subID that are
+// within TTL, it has to be called with the store locked
+func (s *notificationStore) unexpired(subID string) []bufferedNotification {
+	buf := s.m[subID]
+	if s.ttl <= 0 {
+		return buf
+	}
+
+	deadline := time.Now().Add(-s.ttl)
+	i := 0
+	for i < len(buf) && buf[i].timestamp.Before(deadline) {
+		i++
+	}
+	return buf[i:]
+}
@@ -0,0 +1,83 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package eaa
+
+import (
+	"time"
+
+	g "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/open-ness/edgenode/pkg/util"
+)
+
+var _ = g.Describe("notificationStore", func() {
+	const subID = "ns:consumer"
+
+	g.When("store is disabled", func() {
+		g.It("keeps nothing", func() {
+			var nilStore *notificationStore
+			Expect(nilStore.nextSequence()).To(BeZero())
+			nilStore.add(subID, 1, []byte("a"))
+			Expect(nilStore.since(subID, 0)).To(BeEmpty())
+
+			s := newNotificationStore(NotificationBufferConfig{})
+			Expect(s.nextSequence()).To(BeZero())
+			s.add(subID, 1, []byte("a"))
+			Expect(s.since(subID, 0)).To(BeEmpty())
+		})
+	})
+
+	g.When("store is enabled", func() {
+		var s *notificationStore
+
+		g.BeforeEach(func() {
+			s = newNotificationStore(NotificationBufferConfig{Size: 2})
+		})
+
+		g.It("returns notifications after the cursor", func() {
+			for _, p := range []string{"a", "b"} {
+				s.add(subID, s.nextSequence(), []byte(p))
+			}
+
+			Expect(s.since(subID, 0)).To(Equal([][]byte{[]byte("a"), []byte("b")}))
+			Expect(s.since(subID, 1)).To(Equal([][]byte{[]byte("b")}))
+			Expect(s.since(subID, 2)).To(BeEmpty())
+			Expect(s.since("other", 0)).To(BeEmpty())
+		})
+
+		g.It("drops the oldest notifications over the size", func() {
+			for _, p := range []string{"a", "b", "c"} {
+				s.add(subID, s.nextSequence(), []byte(p))
+			}
+
+			Expect(s.since(subID, 0)).To(Equal([][]byte{[]byte("b"), []byte("c")}))
+		})
+
+		g.It("drops expired notifications", func() {
+			s.ttl = 50 * time.Millisecond
+			s.add(subID, s.nextSequence(), []byte("a"))
+			time.Sleep(60 * time.Millisecond)
+			s.add(subID, s.nextSequence(), []byte("b"))
+
+			Expect(s.since(subID, 0)).To(Equal([][]byte{[]byte("b")}))
+		})
+	})
+
+	g.Describe("sendNotificationToSubscriber", func() {
+		g.It("keeps notifications of a disconnected consumer", func() {
+			eaaContext := &Context{}
+			eaaContext.consumerConnections = consumerConns{m: make(map[string]ConsumerConnection)}
+			eaaContext.notificationBuffer = newNotificationStore(
+				NotificationBufferConfig{Size: 10, TTL: util.Duration{Duration: time.Minute}})
+
+			seq := eaaContext.notificationBuffer.nextSequence()
+			e := sendNotificationToSubscriber(subID, seq, []byte("a"), eaaContext)
+			Expect(e).To(HaveOccurred())
+
+			Expect(eaaContext.notificationBuffer.since(subID, 0)).To(
+				Equal([][]byte{[]byte("a")}))
+		})
+	})
+})
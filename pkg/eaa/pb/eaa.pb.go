@@ -0,0 +1,1193 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: eaa.proto
+
+package pb
+
+import (
+	context "context"
+	fmt "fmt"
+	math "math"
+
+	proto "github.com/golang/protobuf/proto"
+	empty "github.com/golang/protobuf/ptypes/empty"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type URN struct {
+	Id                   string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Namespace            string   `protobuf:"bytes,2,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *URN) Reset()         { *m = URN{} }
+func (m *URN) String() string { return proto.CompactTextString(m) }
+func (*URN) ProtoMessage()    {}
+func (*URN) Descriptor() ([]byte, []int) {
+	return fileDescriptor_c55543a9c5978491, []int{0}
+}
+
+func (m *URN) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_URN.Unmarshal(m, b)
+}
+func (m *URN) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_URN.Marshal(b, m, deterministic)
+}
+func (m *URN) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_URN.Merge(m, src)
+}
+func (m *URN) XXX_Size() int {
+	return xxx_messageInfo_URN.Size(m)
+}
+func (m *URN) XXX_DiscardUnknown() {
+	xxx_messageInfo_URN.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_URN proto.InternalMessageInfo
+
+func (m *URN) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *URN) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+type NotificationDescriptor struct {
+	Name        string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Version     string `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	Description string `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	// JSON encoded JSON Schema of the notification payload
+	Schema               []byte   `protobuf:"bytes,4,opt,name=schema,proto3" json:"schema,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *NotificationDescriptor) Reset()         { *m = NotificationDescriptor{} }
+func (m *NotificationDescriptor) String() string { return proto.CompactTextString(m) }
+func (*NotificationDescriptor) ProtoMessage()    {}
+func (*NotificationDescriptor) Descriptor() ([]byte, []int) {
+	return fileDescriptor_c55543a9c5978491, []int{1}
+}
+
+func (m *NotificationDescriptor) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NotificationDescriptor.Unmarshal(m, b)
+}
+func (m *NotificationDescriptor) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NotificationDescriptor.Marshal(b, m, deterministic)
+}
+func (m *NotificationDescriptor) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NotificationDescriptor.Merge(m, src)
+}
+func (m *NotificationDescriptor) XXX_Size() int {
+	return xxx_messageInfo_NotificationDescriptor.Size(m)
+}
+func (m *NotificationDescriptor) XXX_DiscardUnknown() {
+	xxx_messageInfo_NotificationDescriptor.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NotificationDescriptor proto.InternalMessageInfo
+
+func (m *NotificationDescriptor) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *NotificationDescriptor) GetVersion() string {
+	if m != nil {
+		return m.Version
+	}
+	return ""
+}
+
+func (m *NotificationDescriptor) GetDescription() string {
+	if m != nil {
+		return m.Description
+	}
+	return ""
+}
+
+func (m *NotificationDescriptor) GetSchema() []byte {
+	if m != nil {
+		return m.Schema
+	}
+	return nil
+}
+
+type Service struct {
+	Urn           *URN                      `protobuf:"bytes,1,opt,name=urn,proto3" json:"urn,omitempty"`
+	Description   string                    `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	EndpointUri   string                    `protobuf:"bytes,3,opt,name=endpoint_uri,json=endpointUri,proto3" json:"endpoint_uri,omitempty"`
+	Status        string                    `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"`
+	Notifications []*NotificationDescriptor `protobuf:"bytes,5,rep,name=notifications,proto3" json:"notifications,omitempty"`
+	// JSON encoded service specific information
+	Info []byte `protobuf:"bytes,6,opt,name=info,proto3" json:"info,omitempty"`
+	// Capability tags of the service used for discovery
+	Tags []string `protobuf:"bytes,7,rep,name=tags,proto3" json:"tags,omitempty"`
+	// Federation peer hosting the service, empty for services of the local
+	// node. It's ignored on registration.
+	Node                 string   `protobuf:"bytes,8,opt,name=node,proto3" json:"node,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Service) Reset()         { *m = Service{} }
+func (m *Service) String() string { return proto.CompactTextString(m) }
+func (*Service) ProtoMessage()    {}
+func (*Service) Descriptor() ([]byte, []int) {
+	return fileDescriptor_c55543a9c5978491, []int{2}
+}
+
+func (m *Service) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Service.Unmarshal(m, b)
+}
+func (m *Service) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Service.Marshal(b, m, deterministic)
+}
+func (m *Service) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Service.Merge(m, src)
+}
+func (m *Service) XXX_Size() int {
+	return xxx_messageInfo_Service.Size(m)
+}
+func (m *Service) XXX_DiscardUnknown() {
+	xxx_messageInfo_Service.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Service proto.InternalMessageInfo
+
+func (m *Service) GetUrn() *URN {
+	if m != nil {
+		return m.Urn
+	}
+	return nil
+}
+
+func (m *Service) GetDescription() string {
+	if m != nil {
+		return m.Description
+	}
+	return ""
+}
+
+func (m *Service) GetEndpointUri() string {
+	if m != nil {
+		return m.EndpointUri
+	}
+	return ""
+}
+
+func (m *Service) GetStatus() string {
+	if m != nil {
+		return m.Status
+	}
+	return ""
+}
+
+func (m *Service) GetNotifications() []*NotificationDescriptor {
+	if m != nil {
+		return m.Notifications
+	}
+	return nil
+}
+
+func (m *Service) GetInfo() []byte {
+	if m != nil {
+		return m.Info
+	}
+	return nil
+}
+
+func (m *Service) GetTags() []string {
+	if m != nil {
+		return m.Tags
+	}
+	return nil
+}
+
+func (m *Service) GetNode() string {
+	if m != nil {
+		return m.Node
+	}
+	return ""
+}
+
+type ServicesRequest struct {
+	// Namespace of services, any if empty
+	Namespace string `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	// Prefix of "namespace:id" URN of services
+	UrnPrefix string `protobuf:"bytes,2,opt,name=urn_prefix,json=urnPrefix,proto3" json:"urn_prefix,omitempty"`
+	// Tags services must all have
+	Tags []string `protobuf:"bytes,3,rep,name=tags,proto3" json:"tags,omitempty"`
+	// Maximum number of services returned, all if 0
+	Limit int32 `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`
+	// next_page_token of the previous page, the first page if empty
+	PageToken            string   `protobuf:"bytes,5,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ServicesRequest) Reset()         { *m = ServicesRequest{} }
+func (m *ServicesRequest) String() string { return proto.CompactTextString(m) }
+func (*ServicesRequest) ProtoMessage()    {}
+func (*ServicesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_c55543a9c5978491, []int{3}
+}
+
+func (m *ServicesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ServicesRequest.Unmarshal(m, b)
+}
+func (m *ServicesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ServicesRequest.Marshal(b, m, deterministic)
+}
+func (m *ServicesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ServicesRequest.Merge(m, src)
+}
+func (m *ServicesRequest) XXX_Size() int {
+	return xxx_messageInfo_ServicesRequest.Size(m)
+}
+func (m *ServicesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ServicesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ServicesRequest proto.InternalMessageInfo
+
+func (m *ServicesRequest) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *ServicesRequest) GetUrnPrefix() string {
+	if m != nil {
+		return m.UrnPrefix
+	}
+	return ""
+}
+
+func (m *ServicesRequest) GetTags() []string {
+	if m != nil {
+		return m.Tags
+	}
+	return nil
+}
+
+func (m *ServicesRequest) GetLimit() int32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+func (m *ServicesRequest) GetPageToken() string {
+	if m != nil {
+		return m.PageToken
+	}
+	return ""
+}
+
+type ServiceList struct {
+	Services []*Service `protobuf:"bytes,1,rep,name=services,proto3" json:"services,omitempty"`
+	// Token of the next page, empty for the last page
+	NextPageToken        string   `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ServiceList) Reset()         { *m = ServiceList{} }
+func (m *ServiceList) String() string { return proto.CompactTextString(m) }
+func (*ServiceList) ProtoMessage()    {}
+func (*ServiceList) Descriptor() ([]byte, []int) {
+	return fileDescriptor_c55543a9c5978491, []int{4}
+}
+
+func (m *ServiceList) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ServiceList.Unmarshal(m, b)
+}
+func (m *ServiceList) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ServiceList.Marshal(b, m, deterministic)
+}
+func (m *ServiceList) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ServiceList.Merge(m, src)
+}
+func (m *ServiceList) XXX_Size() int {
+	return xxx_messageInfo_ServiceList.Size(m)
+}
+func (m *ServiceList) XXX_DiscardUnknown() {
+	xxx_messageInfo_ServiceList.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ServiceList proto.InternalMessageInfo
+
+func (m *ServiceList) GetServices() []*Service {
+	if m != nil {
+		return m.Services
+	}
+	return nil
+}
+
+func (m *ServiceList) GetNextPageToken() string {
+	if m != nil {
+		return m.NextPageToken
+	}
+	return ""
+}
+
+type Subscription struct {
+	Urn                  *URN                      `protobuf:"bytes,1,opt,name=urn,proto3" json:"urn,omitempty"`
+	Notifications        []*NotificationDescriptor `protobuf:"bytes,2,rep,name=notifications,proto3" json:"notifications,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                  `json:"-"`
+	XXX_unrecognized     []byte                    `json:"-"`
+	XXX_sizecache        int32                     `json:"-"`
+}
+
+func (m *Subscription) Reset()         { *m = Subscription{} }
+func (m *Subscription) String() string { return proto.CompactTextString(m) }
+func (*Subscription) ProtoMessage()    {}
+func (*Subscription) Descriptor() ([]byte, []int) {
+	return fileDescriptor_c55543a9c5978491, []int{5}
+}
+
+func (m *Subscription) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Subscription.Unmarshal(m, b)
+}
+func (m *Subscription) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Subscription.Marshal(b, m, deterministic)
+}
+func (m *Subscription) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Subscription.Merge(m, src)
+}
+func (m *Subscription) XXX_Size() int {
+	return xxx_messageInfo_Subscription.Size(m)
+}
+func (m *Subscription) XXX_DiscardUnknown() {
+	xxx_messageInfo_Subscription.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Subscription proto.InternalMessageInfo
+
+func (m *Subscription) GetUrn() *URN {
+	if m != nil {
+		return m.Urn
+	}
+	return nil
+}
+
+func (m *Subscription) GetNotifications() []*NotificationDescriptor {
+	if m != nil {
+		return m.Notifications
+	}
+	return nil
+}
+
+type SubscriptionList struct {
+	Subscriptions        []*Subscription `protobuf:"bytes,1,rep,name=subscriptions,proto3" json:"subscriptions,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *SubscriptionList) Reset()         { *m = SubscriptionList{} }
+func (m *SubscriptionList) String() string { return proto.CompactTextString(m) }
+func (*SubscriptionList) ProtoMessage()    {}
+func (*SubscriptionList) Descriptor() ([]byte, []int) {
+	return fileDescriptor_c55543a9c5978491, []int{6}
+}
+
+func (m *SubscriptionList) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SubscriptionList.Unmarshal(m, b)
+}
+func (m *SubscriptionList) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SubscriptionList.Marshal(b, m, deterministic)
+}
+func (m *SubscriptionList) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SubscriptionList.Merge(m, src)
+}
+func (m *SubscriptionList) XXX_Size() int {
+	return xxx_messageInfo_SubscriptionList.Size(m)
+}
+func (m *SubscriptionList) XXX_DiscardUnknown() {
+	xxx_messageInfo_SubscriptionList.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SubscriptionList proto.InternalMessageInfo
+
+func (m *SubscriptionList) GetSubscriptions() []*Subscription {
+	if m != nil {
+		return m.Subscriptions
+	}
+	return nil
+}
+
+type NotificationFromProducer struct {
+	Name    string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Version string `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	// JSON encoded notification payload
+	Payload              []byte   `protobuf:"bytes,3,opt,name=payload,proto3" json:"payload,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *NotificationFromProducer) Reset()         { *m = NotificationFromProducer{} }
+func (m *NotificationFromProducer) String() string { return proto.CompactTextString(m) }
+func (*NotificationFromProducer) ProtoMessage()    {}
+func (*NotificationFromProducer) Descriptor() ([]byte, []int) {
+	return fileDescriptor_c55543a9c5978491, []int{7}
+}
+
+func (m *NotificationFromProducer) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NotificationFromProducer.Unmarshal(m, b)
+}
+func (m *NotificationFromProducer) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NotificationFromProducer.Marshal(b, m, deterministic)
+}
+func (m *NotificationFromProducer) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NotificationFromProducer.Merge(m, src)
+}
+func (m *NotificationFromProducer) XXX_Size() int {
+	return xxx_messageInfo_NotificationFromProducer.Size(m)
+}
+func (m *NotificationFromProducer) XXX_DiscardUnknown() {
+	xxx_messageInfo_NotificationFromProducer.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NotificationFromProducer proto.InternalMessageInfo
+
+func (m *NotificationFromProducer) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *NotificationFromProducer) GetVersion() string {
+	if m != nil {
+		return m.Version
+	}
+	return ""
+}
+
+func (m *NotificationFromProducer) GetPayload() []byte {
+	if m != nil {
+		return m.Payload
+	}
+	return nil
+}
+
+type NotificationToConsumer struct {
+	Name    string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Version string `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	// JSON encoded notification payload
+	Payload  []byte `protobuf:"bytes,3,opt,name=payload,proto3" json:"payload,omitempty"`
+	Producer *URN   `protobuf:"bytes,4,opt,name=producer,proto3" json:"producer,omitempty"`
+	// Sequence number of the notification, set only when EAA buffers
+	// notifications for replay
+	Sequence             uint64   `protobuf:"varint,5,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *NotificationToConsumer) Reset()         { *m = NotificationToConsumer{} }
+func (m *NotificationToConsumer) String() string { return proto.CompactTextString(m) }
+func (*NotificationToConsumer) ProtoMessage()    {}
+func (*NotificationToConsumer) Descriptor() ([]byte, []int) {
+	return fileDescriptor_c55543a9c5978491, []int{8}
+}
+
+func (m *NotificationToConsumer) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NotificationToConsumer.Unmarshal(m, b)
+}
+func (m *NotificationToConsumer) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NotificationToConsumer.Marshal(b, m, deterministic)
+}
+func (m *NotificationToConsumer) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NotificationToConsumer.Merge(m, src)
+}
+func (m *NotificationToConsumer) XXX_Size() int {
+	return xxx_messageInfo_NotificationToConsumer.Size(m)
+}
+func (m *NotificationToConsumer) XXX_DiscardUnknown() {
+	xxx_messageInfo_NotificationToConsumer.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NotificationToConsumer proto.InternalMessageInfo
+
+func (m *NotificationToConsumer) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *NotificationToConsumer) GetVersion() string {
+	if m != nil {
+		return m.Version
+	}
+	return ""
+}
+
+func (m *NotificationToConsumer) GetPayload() []byte {
+	if m != nil {
+		return m.Payload
+	}
+	return nil
+}
+
+func (m *NotificationToConsumer) GetProducer() *URN {
+	if m != nil {
+		return m.Producer
+	}
+	return nil
+}
+
+func (m *NotificationToConsumer) GetSequence() uint64 {
+	if m != nil {
+		return m.Sequence
+	}
+	return 0
+}
+
+type NotificationsRequest struct {
+	// Replay buffered notifications with sequence number greater than
+	// since before streaming new ones
+	Replay               bool     `protobuf:"varint,1,opt,name=replay,proto3" json:"replay,omitempty"`
+	Since                uint64   `protobuf:"varint,2,opt,name=since,proto3" json:"since,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *NotificationsRequest) Reset()         { *m = NotificationsRequest{} }
+func (m *NotificationsRequest) String() string { return proto.CompactTextString(m) }
+func (*NotificationsRequest) ProtoMessage()    {}
+func (*NotificationsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_c55543a9c5978491, []int{9}
+}
+
+func (m *NotificationsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NotificationsRequest.Unmarshal(m, b)
+}
+func (m *NotificationsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NotificationsRequest.Marshal(b, m, deterministic)
+}
+func (m *NotificationsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NotificationsRequest.Merge(m, src)
+}
+func (m *NotificationsRequest) XXX_Size() int {
+	return xxx_messageInfo_NotificationsRequest.Size(m)
+}
+func (m *NotificationsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_NotificationsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NotificationsRequest proto.InternalMessageInfo
+
+func (m *NotificationsRequest) GetReplay() bool {
+	if m != nil {
+		return m.Replay
+	}
+	return false
+}
+
+func (m *NotificationsRequest) GetSince() uint64 {
+	if m != nil {
+		return m.Since
+	}
+	return 0
+}
+
+type DeliveryStatistics struct {
+	// Notifications sent for the first time
+	Sent uint64 `protobuf:"varint,1,opt,name=sent,proto3" json:"sent,omitempty"`
+	// Notifications acknowledged by consumers
+	Acknowledged uint64 `protobuf:"varint,2,opt,name=acknowledged,proto3" json:"acknowledged,omitempty"`
+	// Notifications sent again as not acknowledged in time
+	Retried uint64 `protobuf:"varint,3,opt,name=retried,proto3" json:"retried,omitempty"`
+	// Notifications given up after all retries or on disconnection
+	Failed               uint64   `protobuf:"varint,4,opt,name=failed,proto3" json:"failed,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DeliveryStatistics) Reset()         { *m = DeliveryStatistics{} }
+func (m *DeliveryStatistics) String() string { return proto.CompactTextString(m) }
+func (*DeliveryStatistics) ProtoMessage()    {}
+func (*DeliveryStatistics) Descriptor() ([]byte, []int) {
+	return fileDescriptor_c55543a9c5978491, []int{10}
+}
+
+func (m *DeliveryStatistics) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DeliveryStatistics.Unmarshal(m, b)
+}
+func (m *DeliveryStatistics) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DeliveryStatistics.Marshal(b, m, deterministic)
+}
+func (m *DeliveryStatistics) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DeliveryStatistics.Merge(m, src)
+}
+func (m *DeliveryStatistics) XXX_Size() int {
+	return xxx_messageInfo_DeliveryStatistics.Size(m)
+}
+func (m *DeliveryStatistics) XXX_DiscardUnknown() {
+	xxx_messageInfo_DeliveryStatistics.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DeliveryStatistics proto.InternalMessageInfo
+
+func (m *DeliveryStatistics) GetSent() uint64 {
+	if m != nil {
+		return m.Sent
+	}
+	return 0
+}
+
+func (m *DeliveryStatistics) GetAcknowledged() uint64 {
+	if m != nil {
+		return m.Acknowledged
+	}
+	return 0
+}
+
+func (m *DeliveryStatistics) GetRetried() uint64 {
+	if m != nil {
+		return m.Retried
+	}
+	return 0
+}
+
+func (m *DeliveryStatistics) GetFailed() uint64 {
+	if m != nil {
+		return m.Failed
+	}
+	return 0
+}
+
+func init() {
+	proto.RegisterType((*URN)(nil), "pb.URN")
+	proto.RegisterType((*NotificationDescriptor)(nil), "pb.NotificationDescriptor")
+	proto.RegisterType((*Service)(nil), "pb.Service")
+	proto.RegisterType((*ServicesRequest)(nil), "pb.ServicesRequest")
+	proto.RegisterType((*ServiceList)(nil), "pb.ServiceList")
+	proto.RegisterType((*Subscription)(nil), "pb.Subscription")
+	proto.RegisterType((*SubscriptionList)(nil), "pb.SubscriptionList")
+	proto.RegisterType((*NotificationFromProducer)(nil), "pb.NotificationFromProducer")
+	proto.RegisterType((*NotificationToConsumer)(nil), "pb.NotificationToConsumer")
+	proto.RegisterType((*NotificationsRequest)(nil), "pb.NotificationsRequest")
+	proto.RegisterType((*DeliveryStatistics)(nil), "pb.DeliveryStatistics")
+}
+
+func init() { proto.RegisterFile("eaa.proto", fileDescriptor_c55543a9c5978491) }
+
+var fileDescriptor_c55543a9c5978491 = []byte{
+	// 790 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x54, 0x5f, 0x6f, 0x23, 0x35,
+	0x10, 0xef, 0x26, 0xdb, 0xfc, 0x99, 0xa4, 0x34, 0xf2, 0x95, 0x68, 0x09, 0x87, 0x14, 0x16, 0x09,
+	0xfa, 0x94, 0x43, 0x3d, 0x01, 0x42, 0xe2, 0x81, 0x42, 0x8e, 0x13, 0xa7, 0x53, 0x15, 0xb9, 0x97,
+	0x57, 0xa2, 0xfd, 0x33, 0xc9, 0x59, 0xdd, 0xd8, 0x7b, 0xb6, 0xb7, 0x5c, 0x5e, 0x10, 0xdf, 0x82,
+	0x67, 0xbe, 0x1a, 0x5f, 0x04, 0x64, 0xc7, 0x9b, 0x6e, 0x92, 0x36, 0x52, 0xd1, 0xbd, 0x79, 0x7e,
+	0xf6, 0xcc, 0xfc, 0xe6, 0x37, 0xe3, 0x81, 0x36, 0x46, 0xd1, 0x28, 0x97, 0x42, 0x0b, 0x52, 0xcb,
+	0xe3, 0xc1, 0xa7, 0x0b, 0x21, 0x16, 0x19, 0x3e, 0xb3, 0x48, 0x5c, 0xcc, 0x9f, 0xe1, 0x32, 0xd7,
+	0xab, 0xf5, 0x83, 0xf0, 0x39, 0xd4, 0xa7, 0xf4, 0x8a, 0x7c, 0x04, 0x35, 0x96, 0x06, 0xde, 0xd0,
+	0x3b, 0x6f, 0xd3, 0x1a, 0x4b, 0xc9, 0x53, 0x68, 0xf3, 0x68, 0x89, 0x2a, 0x8f, 0x12, 0x0c, 0x6a,
+	0x16, 0xbe, 0x03, 0xc2, 0x3f, 0x3d, 0xe8, 0x5f, 0x09, 0xcd, 0xe6, 0x2c, 0x89, 0x34, 0x13, 0x7c,
+	0x8c, 0x2a, 0x91, 0x2c, 0xd7, 0x42, 0x12, 0x02, 0xbe, 0x79, 0xe7, 0x42, 0xd9, 0x33, 0x09, 0xa0,
+	0x79, 0x8b, 0x52, 0x31, 0xc1, 0x5d, 0xa8, 0xd2, 0x24, 0x43, 0xe8, 0xa4, 0xce, 0xd7, 0xdc, 0xd6,
+	0xed, 0x6d, 0x15, 0x22, 0x7d, 0x68, 0xa8, 0xe4, 0x2d, 0x2e, 0xa3, 0xc0, 0x1f, 0x7a, 0xe7, 0x5d,
+	0xea, 0xac, 0xf0, 0x5f, 0x0f, 0x9a, 0xd7, 0x28, 0x6f, 0x59, 0x82, 0xe4, 0x13, 0xa8, 0x17, 0x92,
+	0xdb, 0x94, 0x9d, 0x8b, 0xe6, 0x28, 0x8f, 0x47, 0x53, 0x7a, 0x45, 0x0d, 0xb6, 0x9b, 0xa0, 0xb6,
+	0x9f, 0xe0, 0x73, 0xe8, 0x22, 0x4f, 0x73, 0xc1, 0xb8, 0x9e, 0x15, 0x92, 0x95, 0x1c, 0x4a, 0x6c,
+	0x2a, 0x99, 0xe5, 0xa0, 0x23, 0x5d, 0x28, 0xcb, 0xa1, 0x4d, 0x9d, 0x45, 0x7e, 0x84, 0x13, 0x5e,
+	0x51, 0x41, 0x05, 0xc7, 0xc3, 0xfa, 0x79, 0xe7, 0x62, 0x60, 0x18, 0xdc, 0x2f, 0x0f, 0xdd, 0x76,
+	0x30, 0x6a, 0x31, 0x3e, 0x17, 0x41, 0xc3, 0xd6, 0x66, 0xcf, 0x06, 0xd3, 0xd1, 0x42, 0x05, 0xcd,
+	0x61, 0xdd, 0x28, 0x68, 0xce, 0x56, 0x55, 0x91, 0x62, 0xd0, 0x72, 0xaa, 0x8a, 0x14, 0xc3, 0xbf,
+	0x3c, 0x38, 0x75, 0x0a, 0x28, 0x8a, 0xef, 0x0a, 0x54, 0x7a, 0xbb, 0x6d, 0xde, 0x4e, 0xdb, 0xc8,
+	0x67, 0x00, 0x85, 0xe4, 0xb3, 0x5c, 0xe2, 0x9c, 0xbd, 0x2f, 0xbb, 0x5a, 0x48, 0x3e, 0xb1, 0xc0,
+	0x26, 0x71, 0xbd, 0x92, 0xf8, 0x0c, 0x8e, 0x33, 0xb6, 0x64, 0xda, 0x56, 0x7e, 0x4c, 0xd7, 0x86,
+	0x09, 0x94, 0x47, 0x0b, 0x9c, 0x69, 0x71, 0x83, 0x3c, 0x38, 0x5e, 0x07, 0x32, 0xc8, 0x1b, 0x03,
+	0x84, 0xbf, 0x41, 0xc7, 0x11, 0x7b, 0xcd, 0x94, 0x26, 0x5f, 0x41, 0x4b, 0x39, 0x9e, 0x81, 0x67,
+	0x15, 0xea, 0x18, 0x85, 0xdc, 0x13, 0xba, 0xb9, 0x24, 0x5f, 0xc2, 0x29, 0xc7, 0xf7, 0x7a, 0x56,
+	0x89, 0xbd, 0x26, 0x79, 0x62, 0xe0, 0xc9, 0x26, 0xfe, 0x0d, 0x74, 0xaf, 0x8b, 0xf8, 0xae, 0x85,
+	0x07, 0xfa, 0xbf, 0xd7, 0xa2, 0xda, 0x23, 0x5b, 0x14, 0xbe, 0x82, 0x5e, 0x35, 0x99, 0xad, 0xe8,
+	0x5b, 0x38, 0x51, 0x15, 0xac, 0x2c, 0xab, 0x67, 0xcb, 0xaa, 0x5c, 0xd0, 0xed, 0x67, 0x61, 0x0c,
+	0x41, 0x35, 0xe9, 0x2f, 0x52, 0x2c, 0x27, 0x52, 0xa4, 0x45, 0x82, 0x8f, 0xfd, 0x38, 0x01, 0x34,
+	0xf3, 0x68, 0x95, 0x89, 0x28, 0xb5, 0x03, 0xdb, 0xa5, 0xa5, 0x19, 0xfe, 0xbd, 0xf3, 0x37, 0xdf,
+	0x88, 0x9f, 0x05, 0x57, 0xc5, 0xf2, 0xc3, 0xa5, 0x20, 0x5f, 0x40, 0x2b, 0x77, 0xb4, 0x03, 0x7f,
+	0x5b, 0xf4, 0xcd, 0x05, 0x19, 0x98, 0xae, 0xbf, 0x2b, 0x90, 0x27, 0x68, 0x27, 0xc4, 0xa7, 0x1b,
+	0x3b, 0x1c, 0xc3, 0x59, 0x95, 0xe2, 0x66, 0x7c, 0xfb, 0xd0, 0x90, 0x98, 0x67, 0xd1, 0xca, 0x52,
+	0x6c, 0x51, 0x67, 0x99, 0x29, 0x54, 0x8c, 0xbb, 0x4d, 0xe4, 0xd3, 0xb5, 0x11, 0xfe, 0x01, 0x64,
+	0x8c, 0x19, 0xbb, 0x45, 0xb9, 0xba, 0xd6, 0x91, 0x66, 0x4a, 0xb3, 0xc4, 0x7e, 0x15, 0x85, 0x5c,
+	0xdb, 0x08, 0x3e, 0xb5, 0x67, 0x12, 0x42, 0x37, 0x4a, 0x6e, 0xb8, 0xf8, 0x3d, 0xc3, 0x74, 0x81,
+	0xa9, 0x0b, 0xb3, 0x85, 0x99, 0x72, 0x25, 0x6a, 0xc9, 0x70, 0x5d, 0xae, 0x4f, 0x4b, 0xd3, 0xb0,
+	0x9a, 0x47, 0x2c, 0xc3, 0xd4, 0x16, 0xeb, 0x53, 0x67, 0x5d, 0xfc, 0xe3, 0x43, 0xfd, 0xc5, 0xe5,
+	0x25, 0xf9, 0x01, 0x9e, 0x50, 0x5c, 0x30, 0xa5, 0x51, 0x5e, 0xe6, 0x79, 0xe6, 0x8a, 0x22, 0xd5,
+	0x21, 0x1f, 0xf4, 0x47, 0xeb, 0x25, 0x3c, 0x2a, 0x97, 0xf0, 0xe8, 0x85, 0x59, 0xc2, 0xe1, 0x11,
+	0xf9, 0x15, 0x3e, 0x1e, 0xa3, 0xbc, 0xc7, 0xff, 0x01, 0x97, 0x03, 0xa1, 0xbe, 0x81, 0xce, 0x4b,
+	0xd4, 0xe5, 0x4e, 0x20, 0x4f, 0x2a, 0x04, 0x4a, 0x89, 0x07, 0xa7, 0x15, 0xd0, 0xcc, 0x72, 0x78,
+	0x44, 0x7e, 0x82, 0x9e, 0x71, 0xab, 0x4e, 0xea, 0x83, 0xc9, 0xcf, 0x76, 0x47, 0xdc, 0xc5, 0x78,
+	0x05, 0xbd, 0x49, 0xa1, 0xde, 0x56, 0xbb, 0x4a, 0x9e, 0xee, 0x7e, 0xb2, 0xea, 0xbc, 0x1f, 0x28,
+	0xe3, 0x3b, 0x68, 0xbb, 0x0c, 0x31, 0x92, 0xbd, 0x3f, 0x75, 0xc0, 0xf1, 0x7b, 0xe8, 0x4c, 0xb9,
+	0xfa, 0x5f, 0xae, 0xaf, 0xad, 0x06, 0x5b, 0x43, 0x49, 0x82, 0x5d, 0xfe, 0x1b, 0x11, 0xf7, 0xd6,
+	0xc7, 0xdd, 0x27, 0x0b, 0x8f, 0xbe, 0xf6, 0x4c, 0x4f, 0x5f, 0xa2, 0xbe, 0x67, 0x38, 0x1f, 0xee,
+	0x69, 0x1e, 0x8f, 0xf6, 0xdf, 0x87, 0x47, 0x71, 0xc3, 0xbe, 0x7c, 0xfe, 0xdf, 0x00, 0x9e, 0xf6,
+	0x99, 0x9b, 0xd4, 0x07, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConnInterface
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion6
+
+// EAAClient is the client API for EAA service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type EAAClient interface {
+	// RegisterApplication registers the calling application as a producer
+	RegisterApplication(ctx context.Context, in *Service, opts ...grpc.CallOption) (*empty.Empty, error)
+	// DeregisterApplication removes the producer registration of the
+	// calling application
+	DeregisterApplication(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*empty.Empty, error)
+	// GetServices returns registered services matching the request, all of
+	// them for an empty request
+	GetServices(ctx context.Context, in *ServicesRequest, opts ...grpc.CallOption) (*ServiceList, error)
+	// GetSubscriptions returns subscriptions of the calling application
+	GetSubscriptions(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*SubscriptionList, error)
+	// PushNotification publishes a notification of the calling producer
+	PushNotification(ctx context.Context, in *NotificationFromProducer, opts ...grpc.CallOption) (*empty.Empty, error)
+	// Subscribe subscribes to notifications of a namespace (urn.id empty)
+	// or of a single service
+	Subscribe(ctx context.Context, in *Subscription, opts ...grpc.CallOption) (*empty.Empty, error)
+	// Unsubscribe removes subscriptions to notifications of a namespace
+	// (urn.id empty), of a single service or all of them (urn not set)
+	Unsubscribe(ctx context.Context, in *Subscription, opts ...grpc.CallOption) (*empty.Empty, error)
+	// GetNotifications streams notifications the calling application is
+	// subscribed to. It replaces any previous notifications connection
+	// (WebSocket or gRPC) of the application.
+	GetNotifications(ctx context.Context, in *NotificationsRequest, opts ...grpc.CallOption) (EAA_GetNotificationsClient, error)
+	// GetDeliveryStatistics returns statistics of delivery of notifications
+	// of the calling producer to consumers acknowledging them. Consumers
+	// acknowledge notifications only over WebSocket.
+	GetDeliveryStatistics(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*DeliveryStatistics, error)
+}
+
+type eAAClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewEAAClient(cc grpc.ClientConnInterface) EAAClient {
+	return &eAAClient{cc}
+}
+
+func (c *eAAClient) RegisterApplication(ctx context.Context, in *Service, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/pb.EAA/RegisterApplication", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eAAClient) DeregisterApplication(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/pb.EAA/DeregisterApplication", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eAAClient) GetServices(ctx context.Context, in *ServicesRequest, opts ...grpc.CallOption) (*ServiceList, error) {
+	out := new(ServiceList)
+	err := c.cc.Invoke(ctx, "/pb.EAA/GetServices", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eAAClient) GetSubscriptions(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*SubscriptionList, error) {
+	out := new(SubscriptionList)
+	err := c.cc.Invoke(ctx, "/pb.EAA/GetSubscriptions", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eAAClient) PushNotification(ctx context.Context, in *NotificationFromProducer, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/pb.EAA/PushNotification", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eAAClient) Subscribe(ctx context.Context, in *Subscription, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/pb.EAA/Subscribe", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eAAClient) Unsubscribe(ctx context.Context, in *Subscription, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/pb.EAA/Unsubscribe", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eAAClient) GetNotifications(ctx context.Context, in *NotificationsRequest, opts ...grpc.CallOption) (EAA_GetNotificationsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_EAA_serviceDesc.Streams[0], "/pb.EAA/GetNotifications", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &eAAGetNotificationsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type EAA_GetNotificationsClient interface {
+	Recv() (*NotificationToConsumer, error)
+	grpc.ClientStream
+}
+
+type eAAGetNotificationsClient struct {
+	grpc.ClientStream
+}
+
+func (x *eAAGetNotificationsClient) Recv() (*NotificationToConsumer, error) {
+	m := new(NotificationToConsumer)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *eAAClient) GetDeliveryStatistics(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*DeliveryStatistics, error) {
+	out := new(DeliveryStatistics)
+	err := c.cc.Invoke(ctx, "/pb.EAA/GetDeliveryStatistics", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// EAAServer is the server API for EAA service.
+type EAAServer interface {
+	// RegisterApplication registers the calling application as a producer
+	RegisterApplication(context.Context, *Service) (*empty.Empty, error)
+	// DeregisterApplication removes the producer registration of the
+	// calling application
+	DeregisterApplication(context.Context, *empty.Empty) (*empty.Empty, error)
+	// GetServices returns registered services matching the request, all of
+	// them for an empty request
+	GetServices(context.Context, *ServicesRequest) (*ServiceList, error)
+	// GetSubscriptions returns subscriptions of the calling application
+	GetSubscriptions(context.Context, *empty.Empty) (*SubscriptionList, error)
+	// PushNotification publishes a notification of the calling producer
+	PushNotification(context.Context, *NotificationFromProducer) (*empty.Empty, error)
+	// Subscribe subscribes to notifications of a namespace (urn.id empty)
+	// or of a single service
+	Subscribe(context.Context, *Subscription) (*empty.Empty, error)
+	// Unsubscribe removes subscriptions to notifications of a namespace
+	// (urn.id empty), of a single service or all of them (urn not set)
+	Unsubscribe(context.Context, *Subscription) (*empty.Empty, error)
+	// GetNotifications streams notifications the calling application is
+	// subscribed to. It replaces any previous notifications connection
+	// (WebSocket or gRPC) of the application.
+	GetNotifications(*NotificationsRequest, EAA_GetNotificationsServer) error
+	// GetDeliveryStatistics returns statistics of delivery of notifications
+	// of the calling producer to consumers acknowledging them. Consumers
+	// acknowledge notifications only over WebSocket.
+	GetDeliveryStatistics(context.Context, *empty.Empty) (*DeliveryStatistics, error)
+}
+
+// UnimplementedEAAServer can be embedded to have forward compatible implementations.
+type UnimplementedEAAServer struct {
+}
+
+func (*UnimplementedEAAServer) RegisterApplication(ctx context.Context, req *Service) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RegisterApplication not implemented")
+}
+func (*UnimplementedEAAServer) DeregisterApplication(ctx context.Context, req *empty.Empty) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeregisterApplication not implemented")
+}
+func (*UnimplementedEAAServer) GetServices(ctx context.Context, req *ServicesRequest) (*ServiceList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetServices not implemented")
+}
+func (*UnimplementedEAAServer) GetSubscriptions(ctx context.Context, req *empty.Empty) (*SubscriptionList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSubscriptions not implemented")
+}
+func (*UnimplementedEAAServer) PushNotification(ctx context.Context, req *NotificationFromProducer) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PushNotification not implemented")
+}
+func (*UnimplementedEAAServer) Subscribe(ctx context.Context, req *Subscription) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Subscribe not implemented")
+}
+func (*UnimplementedEAAServer) Unsubscribe(ctx context.Context, req *Subscription) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Unsubscribe not implemented")
+}
+func (*UnimplementedEAAServer) GetNotifications(req *NotificationsRequest, srv EAA_GetNotificationsServer) error {
+	return status.Errorf(codes.Unimplemented, "method GetNotifications not implemented")
+}
+func (*UnimplementedEAAServer) GetDeliveryStatistics(ctx context.Context, req *empty.Empty) (*DeliveryStatistics, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDeliveryStatistics not implemented")
+}
+
+func RegisterEAAServer(s *grpc.Server, srv EAAServer) {
+	s.RegisterService(&_EAA_serviceDesc, srv)
+}
+
+func _EAA_RegisterApplication_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Service)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EAAServer).RegisterApplication(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.EAA/RegisterApplication",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EAAServer).RegisterApplication(ctx, req.(*Service))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EAA_DeregisterApplication_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EAAServer).DeregisterApplication(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.EAA/DeregisterApplication",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EAAServer).DeregisterApplication(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EAA_GetServices_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ServicesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EAAServer).GetServices(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.EAA/GetServices",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EAAServer).GetServices(ctx, req.(*ServicesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EAA_GetSubscriptions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EAAServer).GetSubscriptions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.EAA/GetSubscriptions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EAAServer).GetSubscriptions(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EAA_PushNotification_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NotificationFromProducer)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EAAServer).PushNotification(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.EAA/PushNotification",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EAAServer).PushNotification(ctx, req.(*NotificationFromProducer))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EAA_Subscribe_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Subscription)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EAAServer).Subscribe(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.EAA/Subscribe",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EAAServer).Subscribe(ctx, req.(*Subscription))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EAA_Unsubscribe_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Subscription)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EAAServer).Unsubscribe(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.EAA/Unsubscribe",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EAAServer).Unsubscribe(ctx, req.(*Subscription))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EAA_GetNotifications_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(NotificationsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(EAAServer).GetNotifications(m, &eAAGetNotificationsServer{stream})
+}
+
+type EAA_GetNotificationsServer interface {
+	Send(*NotificationToConsumer) error
+	grpc.ServerStream
+}
+
+type eAAGetNotificationsServer struct {
+	grpc.ServerStream
+}
+
+func (x *eAAGetNotificationsServer) Send(m *NotificationToConsumer) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _EAA_GetDeliveryStatistics_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EAAServer).GetDeliveryStatistics(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.EAA/GetDeliveryStatistics",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EAAServer).GetDeliveryStatistics(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _EAA_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "pb.EAA",
+	HandlerType: (*EAAServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "RegisterApplication",
+			Handler:    _EAA_RegisterApplication_Handler,
+		},
+		{
+			MethodName: "DeregisterApplication",
+			Handler:    _EAA_DeregisterApplication_Handler,
+		},
+		{
+			MethodName: "GetServices",
+			Handler:    _EAA_GetServices_Handler,
+		},
+		{
+			MethodName: "GetSubscriptions",
+			Handler:    _EAA_GetSubscriptions_Handler,
+		},
+		{
+			MethodName: "PushNotification",
+			Handler:    _EAA_PushNotification_Handler,
+		},
+		{
+			MethodName: "Subscribe",
+			Handler:    _EAA_Subscribe_Handler,
+		},
+		{
+			MethodName: "Unsubscribe",
+			Handler:    _EAA_Unsubscribe_Handler,
+		},
+		{
+			MethodName: "GetDeliveryStatistics",
+			Handler:    _EAA_GetDeliveryStatistics_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "GetNotifications",
+			Handler:       _EAA_GetNotifications_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "eaa.proto",
+}
@@ -0,0 +1,140 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package eaa
+
+import (
+	"sync"
+	"time"
+)
+
+// tokenBucket allows on average rate events per second with bursts of up
+// to burst events
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int, now time.Time) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   now,
+	}
+}
+
+// take takes a token if available
+func (b *tokenBucket) take(now time.Time) bool {
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// clientLimiter enforces RateLimitConfig limits per client certificate
+// Common Name. A nil clientLimiter limits nothing.
+type clientLimiter struct {
+	sync.Mutex
+	cfg           RateLimitConfig
+	requests      map[string]*tokenBucket
+	notifications map[string]*tokenBucket
+	inFlight      map[string]int
+}
+
+func newClientLimiter(cfg RateLimitConfig) *clientLimiter {
+	return &clientLimiter{
+		cfg:           cfg,
+		requests:      make(map[string]*tokenBucket),
+		notifications: make(map[string]*tokenBucket),
+		inFlight:      make(map[string]int),
+	}
+}
+
+// startRequest checks the request rate and the concurrent requests limits
+// of the client. When the request is allowed, finishRequest has to be
+// called after it's handled.
+func (l *clientLimiter) startRequest(commonName string) bool {
+	if l == nil {
+		return true
+	}
+
+	l.Lock()
+	defer l.Unlock()
+
+	if l.cfg.MaxConcurrentRequests > 0 &&
+		l.inFlight[commonName] >= l.cfg.MaxConcurrentRequests {
+		log.Warningf("Too many concurrent requests from %s", commonName)
+		return false
+	}
+	if !l.take(l.requests, l.cfg.RequestsPerSecond, l.cfg.RequestBurst,
+		commonName) {
+		log.Warningf("Request rate limit of %s exceeded", commonName)
+		return false
+	}
+
+	l.inFlight[commonName]++
+	return true
+}
+
+// finishRequest releases the request started by startRequest
+func (l *clientLimiter) finishRequest(commonName string) {
+	if l == nil {
+		return
+	}
+
+	l.Lock()
+	defer l.Unlock()
+
+	if l.inFlight[commonName] <= 1 {
+		delete(l.inFlight, commonName)
+	} else {
+		l.inFlight[commonName]--
+	}
+}
+
+// allowNotification checks the notification publish rate limit of
+// the producer
+func (l *clientLimiter) allowNotification(commonName string) bool {
+	if l == nil {
+		return true
+	}
+
+	l.Lock()
+	defer l.Unlock()
+
+	if !l.take(l.notifications, l.cfg.NotificationsPerSecond,
+		l.cfg.NotificationBurst, commonName) {
+		log.Warningf("Notification rate limit of %s exceeded", commonName)
+		return false
+	}
+	return true
+}
+
+// take takes a token from the bucket of the client, zero rate means no limit
+func (l *clientLimiter) take(buckets map[string]*tokenBucket, rate float64,
+	burst int, commonName string) bool {
+	if rate <= 0 {
+		return true
+	}
+
+	now := time.Now()
+	bucket, found := buckets[commonName]
+	if !found {
+		bucket = newTokenBucket(rate, burst, now)
+		buckets[commonName] = bucket
+	}
+	return bucket.take(now)
+}
@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package eaa
+
+import (
+	"time"
+
+	g "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = g.Describe("tokenBucket", func() {
+	g.It("allows bursts and refills with the rate", func() {
+		now := time.Now()
+		b := newTokenBucket(10, 2, now)
+
+		Expect(b.take(now)).To(BeTrue())
+		Expect(b.take(now)).To(BeTrue())
+		Expect(b.take(now)).To(BeFalse())
+
+		now = now.Add(100 * time.Millisecond)
+		Expect(b.take(now)).To(BeTrue())
+		Expect(b.take(now)).To(BeFalse())
+
+		now = now.Add(time.Minute)
+		Expect(b.take(now)).To(BeTrue())
+		Expect(b.take(now)).To(BeTrue())
+		Expect(b.take(now)).To(BeFalse())
+	})
+})
+
+var _ = g.Describe("clientLimiter", func() {
+	g.It("limits nothing when nil", func() {
+		var l *clientLimiter
+		Expect(l.startRequest("ns:app")).To(BeTrue())
+		l.finishRequest("ns:app")
+		Expect(l.allowNotification("ns:app")).To(BeTrue())
+	})
+
+	g.It("limits concurrent requests per client", func() {
+		l := newClientLimiter(RateLimitConfig{MaxConcurrentRequests: 1})
+
+		Expect(l.startRequest("ns:app1")).To(BeTrue())
+		Expect(l.startRequest("ns:app1")).To(BeFalse())
+		Expect(l.startRequest("ns:app2")).To(BeTrue())
+
+		l.finishRequest("ns:app1")
+		Expect(l.startRequest("ns:app1")).To(BeTrue())
+	})
+
+	g.It("limits request rate per client", func() {
+		l := newClientLimiter(RateLimitConfig{RequestsPerSecond: 0.001,
+			RequestBurst: 2})
+
+		for i := 0; i < 2; i++ {
+			Expect(l.startRequest("ns:app1")).To(BeTrue())
+			l.finishRequest("ns:app1")
+		}
+		Expect(l.startRequest("ns:app1")).To(BeFalse())
+		Expect(l.startRequest("ns:app2")).To(BeTrue())
+	})
+
+	g.It("limits notification rate per producer", func() {
+		l := newClientLimiter(RateLimitConfig{NotificationsPerSecond: 0.001})
+
+		Expect(l.allowNotification("ns:producer1")).To(BeTrue())
+		Expect(l.allowNotification("ns:producer1")).To(BeFalse())
+		Expect(l.allowNotification("ns:producer2")).To(BeTrue())
+		Expect(l.startRequest("ns:producer1")).To(BeTrue())
+	})
+})
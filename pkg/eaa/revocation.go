@@ -0,0 +1,133 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package eaa
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// revocationList rejects client certificates revoked by the CRL file.
+// The file is loaded again when it changes, a CRL not signed by any of CA
+// root certificates is ignored. A nil revocationList rejects nothing.
+type revocationList struct {
+	sync.RWMutex
+	path    string
+	cas     []*x509.Certificate
+	modTime time.Time
+	revoked map[string]bool
+}
+
+func newRevocationList(crlPath, caPath string) (*revocationList, error) {
+	data, err := ioutil.ReadFile(filepath.Clean(caPath))
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to read CA root certificates")
+	}
+
+	l := &revocationList{path: crlPath}
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		ca, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to parse CA root certificate")
+		}
+		l.cas = append(l.cas, ca)
+	}
+
+	if err = l.reload(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// reload loads the CRL file again if it was modified since the last load
+func (l *revocationList) reload() error {
+	info, err := os.Stat(l.path)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to stat CRL %s", l.path)
+	}
+
+	l.RLock()
+	modified := !info.ModTime().Equal(l.modTime)
+	l.RUnlock()
+	if !modified {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(filepath.Clean(l.path))
+	if err != nil {
+		return errors.Wrapf(err, "Failed to read CRL %s", l.path)
+	}
+	crl, err := x509.ParseCRL(data)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to parse CRL %s", l.path)
+	}
+
+	signed := false
+	for _, ca := range l.cas {
+		if ca.CheckCRLSignature(crl) == nil {
+			signed = true
+			break
+		}
+	}
+	if !signed {
+		return errors.Errorf("CRL %s is not signed by a CA root certificate",
+			l.path)
+	}
+	if crl.HasExpired(time.Now()) {
+		log.Warningf("CRL %s is past its next update time", l.path)
+	}
+
+	revoked := make(map[string]bool)
+	for _, cert := range crl.TBSCertList.RevokedCertificates {
+		revoked[cert.SerialNumber.String()] = true
+	}
+
+	l.Lock()
+	l.modTime = info.ModTime()
+	l.revoked = revoked
+	l.Unlock()
+
+	log.Infof("Loaded CRL %s with %d revoked certificates", l.path,
+		len(revoked))
+	return nil
+}
+
+// verifyPeerCertificate is tls.Config.VerifyPeerCertificate rejecting
+// revoked client certificates
+func (l *revocationList) verifyPeerCertificate(_ [][]byte,
+	verifiedChains [][]*x509.Certificate) error {
+	if l == nil {
+		return nil
+	}
+
+	// A broken update of the CRL file keeps the previously loaded CRL
+	if err := l.reload(); err != nil {
+		log.Errf("Failed to reload CRL: %v", err)
+	}
+
+	l.RLock()
+	defer l.RUnlock()
+
+	for _, chain := range verifiedChains {
+		for _, cert := range chain {
+			if l.revoked[cert.SerialNumber.String()] {
+				log.Infof("Rejected revoked certificate of %s",
+					cert.Subject.CommonName)
+				return errors.Errorf("certificate %s is revoked",
+					cert.SerialNumber)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package eaa
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	g "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = g.Describe("revocationList", func() {
+	var (
+		dir     string
+		caKey   *ecdsa.PrivateKey
+		ca      *x509.Certificate
+		crlPath string
+	)
+
+	newCert := func(serial int64) *x509.Certificate {
+		return &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: "ns:app"},
+		}
+	}
+
+	writeCRL := func(key *ecdsa.PrivateKey, modTime time.Time,
+		serials ...int64) {
+		var revoked []pkix.RevokedCertificate
+		for _, serial := range serials {
+			revoked = append(revoked, pkix.RevokedCertificate{
+				SerialNumber:   big.NewInt(serial),
+				RevocationTime: time.Now(),
+			})
+		}
+		crl, err := ca.CreateCRL(rand.Reader, key, revoked, time.Now(),
+			time.Now().Add(time.Hour))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(ioutil.WriteFile(crlPath, pem.EncodeToMemory(
+			&pem.Block{Type: "X509 CRL", Bytes: crl}), 0600)).To(Succeed())
+		Expect(os.Chtimes(crlPath, modTime, modTime)).To(Succeed())
+	}
+
+	verify := func(l *revocationList, serial int64) error {
+		return l.verifyPeerCertificate(nil,
+			[][]*x509.Certificate{{newCert(serial), ca}})
+	}
+
+	g.BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "eaa-crl")
+		Expect(err).ShouldNot(HaveOccurred())
+		crlPath = filepath.Join(dir, "crl.pem")
+
+		caKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).ShouldNot(HaveOccurred())
+		template := &x509.Certificate{
+			SerialNumber:          big.NewInt(1),
+			Subject:               pkix.Name{CommonName: "eaa-root"},
+			NotBefore:             time.Now().Add(-time.Hour),
+			NotAfter:              time.Now().Add(time.Hour),
+			IsCA:                  true,
+			BasicConstraintsValid: true,
+			KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, template,
+			&caKey.PublicKey, caKey)
+		Expect(err).ShouldNot(HaveOccurred())
+		ca, err = x509.ParseCertificate(der)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "root.pem"),
+			pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+			0600)).To(Succeed())
+	})
+
+	g.AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	g.It("rejects revoked certificates", func() {
+		writeCRL(caKey, time.Now(), 10)
+		l, err := newRevocationList(crlPath, filepath.Join(dir, "root.pem"))
+		Expect(err).ShouldNot(HaveOccurred())
+
+		Expect(verify(l, 10)).To(HaveOccurred())
+		Expect(verify(l, 11)).To(Succeed())
+	})
+
+	g.It("reloads the modified CRL", func() {
+		writeCRL(caKey, time.Now().Add(-time.Minute), 10)
+		l, err := newRevocationList(crlPath, filepath.Join(dir, "root.pem"))
+		Expect(err).ShouldNot(HaveOccurred())
+
+		writeCRL(caKey, time.Now(), 11)
+		Expect(verify(l, 10)).To(Succeed())
+		Expect(verify(l, 11)).To(HaveOccurred())
+	})
+
+	g.It("ignores a CRL not signed by the CA", func() {
+		writeCRL(caKey, time.Now().Add(-time.Minute), 10)
+		l, err := newRevocationList(crlPath, filepath.Join(dir, "root.pem"))
+		Expect(err).ShouldNot(HaveOccurred())
+
+		otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).ShouldNot(HaveOccurred())
+		writeCRL(otherKey, time.Now(), 11)
+		Expect(verify(l, 10)).To(HaveOccurred())
+		Expect(verify(l, 11)).To(Succeed())
+
+		_, err = newRevocationList(crlPath, filepath.Join(dir, "root.pem"))
+		Expect(err).To(HaveOccurred())
+	})
+
+	g.It("rejects nothing when nil", func() {
+		var l *revocationList
+		Expect(verify(l, 10)).To(Succeed())
+	})
+})
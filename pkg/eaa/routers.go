@@ -34,12 +34,24 @@ func NewEaaRouter(eaaCtx *Context) *mux.Router {
 			Name(route.Name).
 			Handler(route.HandlerFunc)
 	}
+	router.Use(correlationMiddleware)
+	router.Use(tokenAuthMiddleware(eaaCtx))
+	router.Use(auditMiddleware(eaaCtx))
 	router.Use(func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			ctx := context.WithValue(
 				r.Context(),
 				contextKey("appliance-ctx"),
 				eaaCtx)
+			if commonName := clientCommonName(r); commonName != "" {
+				if !eaaCtx.limiter.startRequest(commonName) {
+					writeError(w, r, http.StatusTooManyRequests,
+						ErrorCodeRateLimited, "request rate limit exceeded")
+					return
+				}
+				defer eaaCtx.limiter.finishRequest(commonName)
+				refreshStaleService(commonName, eaaCtx)
+			}
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	})
@@ -54,6 +66,13 @@ var eaaRoutes = Routes{
 		DeregisterApplication,
 	},
 
+	Route{
+		"GetDeliveryStatistics",
+		strings.ToUpper("Get"),
+		"/notifications/statistics",
+		GetDeliveryStatistics,
+	},
+
 	Route{
 		"GetNotifications",
 		strings.ToUpper("Get"),
@@ -0,0 +1,82 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package eaa_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/open-ness/edgenode/pkg/eaa"
+)
+
+var _ = Describe("In-process EAA", func() {
+	It("Serves on ephemeral ports without a config file", func() {
+		var eaaCtx eaa.Context
+		Expect(eaa.InitEaaContextFromConfig(eaa.Config{
+			TLSEndpoint:  "localhost:0",
+			GrpcEndpoint: "localhost:0",
+			Certs: eaa.CertsInfo{
+				CaRootKeyPath:  tempConfCaRootKeyPath,
+				CaRootPath:     tempConfCaRootPath,
+				ServerCertPath: tempConfServerCertPath,
+				ServerKeyPath:  tempConfServerKeyPath,
+				CommonName:     EaaCommonName,
+			},
+		}, &eaaCtx)).To(Succeed())
+		eaaCtx.MsgBrokerCtx = eaa.NewGoChannelMsgBroker(&eaaCtx)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		stopped := make(chan error, 1)
+		go func() {
+			stopped <- eaa.RunServer(ctx, &eaaCtx)
+		}()
+		Eventually(eaaCtx.Ready(), 2*time.Second).Should(BeClosed())
+		Expect(eaaCtx.TLSAddr()).ToNot(HaveSuffix(":0"))
+		Expect(eaaCtx.GrpcAddr()).ToNot(HaveSuffix(":0"))
+
+		certTempl := GetCertTempl()
+		certTempl.Subject.CommonName = AccessName
+		client := createHTTPClient(generateSignedClientCert(&certTempl))
+		resp, err := client.Get("https://" + eaaCtx.TLSAddr() + "/services")
+		Expect(err).ShouldNot(HaveOccurred())
+		resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+		cancel()
+		Eventually(stopped, 2*time.Second).Should(Receive(BeNil()))
+	})
+
+	It("Reports failure to start serving", func() {
+		lis, err := net.Listen("tcp", "localhost:0")
+		Expect(err).ShouldNot(HaveOccurred())
+		defer lis.Close()
+
+		var eaaCtx eaa.Context
+		Expect(eaa.InitEaaContextFromConfig(eaa.Config{
+			TLSEndpoint: lis.Addr().String(),
+			Certs: eaa.CertsInfo{
+				CaRootKeyPath:  tempConfCaRootKeyPath,
+				CaRootPath:     tempConfCaRootPath,
+				ServerCertPath: tempConfServerCertPath,
+				ServerKeyPath:  tempConfServerKeyPath,
+				CommonName:     EaaCommonName,
+			},
+		}, &eaaCtx)).To(Succeed())
+		eaaCtx.MsgBrokerCtx = eaa.NewGoChannelMsgBroker(&eaaCtx)
+
+		stopped := make(chan error, 1)
+		go func() {
+			stopped <- eaa.RunServer(context.Background(), &eaaCtx)
+		}()
+		Eventually(eaaCtx.Ready(), 2*time.Second).Should(BeClosed())
+		Expect(eaaCtx.StartErr()).To(HaveOccurred())
+		Eventually(stopped, 2*time.Second).Should(Receive(HaveOccurred()))
+	})
+})
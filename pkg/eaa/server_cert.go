@@ -0,0 +1,101 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package eaa
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// serverCertificate serves the EAA server certificate to TLS handshakes and
+// loads it again when the certificate or key file changes, so that
+// the certificate can be rotated without a restart. Connections established
+// before the rotation are kept.
+type serverCertificate struct {
+	sync.RWMutex
+	certPath string
+	keyPath  string
+	// modTimes of the files at the last load attempt
+	certModTime time.Time
+	keyModTime  time.Time
+	cert        *tls.Certificate
+	leaf        *x509.Certificate
+}
+
+func newServerCertificate(certPath, keyPath string) (*serverCertificate,
+	error) {
+	c := &serverCertificate{certPath: certPath, keyPath: keyPath}
+	if err := c.reload(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// reload loads the key pair again if any of the files was modified since
+// the last attempt. A pair that fails to load, e.g. because only one of
+// the files is updated so far, keeps the current certificate in use.
+func (c *serverCertificate) reload() error {
+	certInfo, err := os.Stat(c.certPath)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to stat %s", c.certPath)
+	}
+	keyInfo, err := os.Stat(c.keyPath)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to stat %s", c.keyPath)
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	if certInfo.ModTime().Equal(c.certModTime) &&
+		keyInfo.ModTime().Equal(c.keyModTime) {
+		return nil
+	}
+	c.certModTime = certInfo.ModTime()
+	c.keyModTime = keyInfo.ModTime()
+
+	cert, err := tls.LoadX509KeyPair(c.certPath, c.keyPath)
+	if err != nil {
+		return errors.Wrap(err, "Failed to load server key pair")
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return errors.Wrap(err, "Failed to parse server certificate")
+	}
+	if err = validateCert(leaf); err != nil {
+		return errors.Wrap(err, "Server certificate validation failed")
+	}
+
+	if c.cert != nil {
+		log.Infof("Rotated server certificate, valid to: %s", leaf.NotAfter)
+	}
+	c.cert = &cert
+	c.leaf = leaf
+	return nil
+}
+
+// getCertificate is tls.Config.GetCertificate serving the current
+// certificate
+func (c *serverCertificate) getCertificate(
+	*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	if err := c.reload(); err != nil {
+		log.Errf("Failed to reload server certificate: %v", err)
+	}
+
+	c.RLock()
+	defer c.RUnlock()
+	return c.cert, nil
+}
+
+// certificate returns the current certificate
+func (c *serverCertificate) certificate() *x509.Certificate {
+	c.RLock()
+	defer c.RUnlock()
+	return c.leaf
+}
@@ -0,0 +1,90 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package eaa
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	g "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = g.Describe("serverCertificate", func() {
+	var dir, certPath, keyPath string
+
+	// writeKeyPair writes a new self-signed key pair modified at modTime
+	writeKeyPair := func(serial int64, modTime time.Time) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).ShouldNot(HaveOccurred())
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: "eaa.openness"},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, template,
+			&key.PublicKey, key)
+		Expect(err).ShouldNot(HaveOccurred())
+		keyDer, err := x509.MarshalECPrivateKey(key)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		Expect(ioutil.WriteFile(certPath, pem.EncodeToMemory(
+			&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)).To(Succeed())
+		Expect(ioutil.WriteFile(keyPath, pem.EncodeToMemory(
+			&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)).
+			To(Succeed())
+		Expect(os.Chtimes(certPath, modTime, modTime)).To(Succeed())
+		Expect(os.Chtimes(keyPath, modTime, modTime)).To(Succeed())
+	}
+
+	servedSerial := func(c *serverCertificate) int64 {
+		cert, err := c.getCertificate(nil)
+		Expect(err).ShouldNot(HaveOccurred())
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		Expect(err).ShouldNot(HaveOccurred())
+		return leaf.SerialNumber.Int64()
+	}
+
+	g.BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "eaa-cert")
+		Expect(err).ShouldNot(HaveOccurred())
+		certPath = filepath.Join(dir, "cert.pem")
+		keyPath = filepath.Join(dir, "key.pem")
+	})
+
+	g.AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	g.It("serves the rotated certificate", func() {
+		writeKeyPair(1, time.Now().Add(-time.Minute))
+		c, err := newServerCertificate(certPath, keyPath)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(servedSerial(c)).To(BeEquivalentTo(1))
+
+		writeKeyPair(2, time.Now())
+		Expect(servedSerial(c)).To(BeEquivalentTo(2))
+		Expect(c.certificate().SerialNumber.Int64()).To(BeEquivalentTo(2))
+	})
+
+	g.It("keeps the certificate when the new one fails to load", func() {
+		writeKeyPair(1, time.Now().Add(-time.Minute))
+		c, err := newServerCertificate(certPath, keyPath)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		Expect(ioutil.WriteFile(keyPath, []byte("broken"), 0600)).To(Succeed())
+		Expect(servedSerial(c)).To(BeEquivalentTo(1))
+	})
+})
@@ -0,0 +1,217 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package eaa
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// Service liveness statuses sent to consumers of a producer in
+// the serviceStatusNotification
+const (
+	serviceStatusExpired      = "expired"
+	serviceStatusActive       = "active"
+	serviceStatusDeregistered = "deregistered"
+)
+
+// Notification sent by EAA to consumers subscribed to any notification of
+// a producer when liveness of its service changes
+const (
+	serviceStatusNotification        = "eaa-service-status"
+	serviceStatusNotificationVersion = "1.0.0"
+)
+
+// minServiceLivenessTTL is the shortest TTL accepted in the configuration,
+// services are checked every half of TTL
+const minServiceLivenessTTL = time.Second
+
+// ServiceStatusPayload is the payload of the service status notification
+type ServiceStatusPayload struct {
+	Status string `json:"status"`
+}
+
+// serviceLiveness tracks when services were registered last. A producer
+// keeps its service alive by registering it again within TTL, otherwise
+// the service is marked expired and removed after GracePeriod. A nil
+// serviceLiveness tracks nothing.
+type serviceLiveness struct {
+	sync.Mutex
+	ttl         time.Duration
+	gracePeriod time.Duration
+	lastSeen    map[string]time.Time
+	expired     map[string]bool
+}
+
+func newServiceLiveness(cfg ServiceLivenessConfig) *serviceLiveness {
+	return &serviceLiveness{
+		ttl:         cfg.TTL.Duration,
+		gracePeriod: cfg.GracePeriod.Duration,
+		lastSeen:    make(map[string]time.Time),
+		expired:     make(map[string]bool),
+	}
+}
+
+// serviceUpdated is called for every handled service message, registration
+// refreshes the service
+func (l *serviceLiveness) serviceUpdated(commonName string,
+	svcMsg *ServiceMessage, eaaCtx *Context) {
+	if l == nil {
+		return
+	}
+
+	l.Lock()
+	wasExpired := l.expired[commonName]
+	delete(l.expired, commonName)
+	if svcMsg.Action == serviceActionRegister {
+		l.lastSeen[commonName] = time.Now()
+	} else {
+		delete(l.lastSeen, commonName)
+	}
+	l.Unlock()
+
+	if wasExpired && svcMsg.Action == serviceActionRegister {
+		notifyServiceStatus(svcMsg.Svc.URN, serviceStatusActive, eaaCtx)
+	}
+}
+
+// watch checks liveness of services until ctx is done
+func (l *serviceLiveness) watch(ctx context.Context, eaaCtx *Context) {
+	if l == nil || l.ttl <= 0 {
+		return
+	}
+
+	interval := l.ttl / 2
+	if interval <= 0 {
+		interval = l.ttl
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			l.check(eaaCtx)
+		}
+	}
+}
+
+// check marks services not refreshed within TTL expired and removes those
+// not refreshed within TTL and GracePeriod
+func (l *serviceLiveness) check(eaaCtx *Context) {
+	var toExpire, toRemove []string
+
+	l.Lock()
+	now := time.Now()
+	for commonName, lastSeen := range l.lastSeen {
+		age := now.Sub(lastSeen)
+		switch {
+		case age > l.ttl+l.gracePeriod:
+			toRemove = append(toRemove, commonName)
+			delete(l.lastSeen, commonName)
+			delete(l.expired, commonName)
+		case age > l.ttl && !l.expired[commonName]:
+			toExpire = append(toExpire, commonName)
+			l.expired[commonName] = true
+		}
+	}
+	l.Unlock()
+
+	for _, commonName := range toExpire {
+		eaaCtx.serviceInfo.Lock()
+		serv, found := eaaCtx.serviceInfo.m[commonName]
+		if found {
+			serv.Status = serviceStatusExpired
+			eaaCtx.serviceInfo.m[commonName] = serv
+		}
+		eaaCtx.serviceInfo.Unlock()
+
+		if found {
+			log.Infof("Service '%v' expired", commonName)
+			notifyServiceStatus(serv.URN, serviceStatusExpired, eaaCtx)
+		}
+	}
+
+	for _, commonName := range toRemove {
+		eaaCtx.serviceInfo.RLock()
+		serv, found := eaaCtx.serviceInfo.m[commonName]
+		eaaCtx.serviceInfo.RUnlock()
+		if !found {
+			continue
+		}
+
+		if err := removeService(commonName, eaaCtx); err != nil {
+			log.Errf("Failed to remove expired service '%v': %v", commonName, err)
+			continue
+		}
+		notifyServiceStatus(serv.URN, serviceStatusDeregistered, eaaCtx)
+	}
+
+	if len(toExpire) > 0 || len(toRemove) > 0 {
+		eaaCtx.state.save(eaaCtx)
+	}
+}
+
+// notifyServiceStatus sends the service status notification to consumers
+// subscribed to any notification of the producer
+func notifyServiceStatus(prodURN *URN, status string, eaaCtx *Context) {
+	if prodURN == nil {
+		return
+	}
+
+	payload, err := json.Marshal(ServiceStatusPayload{Status: status})
+	if err != nil {
+		log.Errf("Failed to marshal service status: %v", err)
+		return
+	}
+	seq := eaaCtx.notificationBuffer.nextSequence()
+	msgPayload, err := json.Marshal(NotificationToConsumer{
+		Name:     serviceStatusNotification,
+		Version:  serviceStatusNotificationVersion,
+		Payload:  payload,
+		URN:      *prodURN,
+		Sequence: seq,
+	})
+	if err != nil {
+		log.Errf("Failed to marshal service status notification: %v", err)
+		return
+	}
+
+	for _, subID := range getServiceSubscribers(prodURN, eaaCtx) {
+		err = sendNotificationToSubscriber(subID, seq, msgPayload, eaaCtx)
+		if err != nil {
+			log.Warningf("Couldn't send service status to Subscriber ID: %s : %v",
+				subID, err)
+		}
+	}
+}
+
+// getServiceSubscribers returns consumers subscribed to any notification of
+// the producer, either to its namespace or to the service
+func getServiceSubscribers(prodURN *URN, eaaCtx *Context) []string {
+	eaaCtx.subscriptionInfo.RLock()
+	defer eaaCtx.subscriptionInfo.RUnlock()
+
+	found := make(map[string]bool)
+	var subscribers []string
+	add := func(subIDs SubscriberIds) {
+		for _, subID := range subIDs {
+			if !found[subID] {
+				found[subID] = true
+				subscribers = append(subscribers, subID)
+			}
+		}
+	}
+
+	for key, conSub := range eaaCtx.subscriptionInfo.m {
+		if key.namespace == prodURN.Namespace {
+			add(conSub.namespaceSubscriptions)
+			add(conSub.serviceSubscriptions[prodURN.ID])
+		}
+	}
+	return subscribers
+}
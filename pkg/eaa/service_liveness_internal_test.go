@@ -0,0 +1,125 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package eaa
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	g "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/open-ness/edgenode/pkg/util"
+)
+
+var _ = g.Describe("serviceLiveness", func() {
+	const (
+		prodCN = "ns1:producer"
+		consCN = "ns1:consumer"
+	)
+
+	var (
+		eaaCtx *Context
+		urn    URN
+	)
+
+	register := func() {
+		serv := Service{URN: &urn, Status: "ready"}
+		Expect(addService(prodCN, serv, eaaCtx)).To(Succeed())
+		eaaCtx.liveness.serviceUpdated(prodCN,
+			&ServiceMessage{Svc: &serv, Action: serviceActionRegister}, eaaCtx)
+	}
+
+	// receivedStatuses returns statuses from the service status notifications
+	// kept for the consumer
+	receivedStatuses := func() []string {
+		var statuses []string
+		for _, payload := range eaaCtx.notificationBuffer.since(consCN, 0) {
+			var notif NotificationToConsumer
+			Expect(json.Unmarshal(payload, &notif)).To(Succeed())
+			Expect(notif.Name).To(Equal(serviceStatusNotification))
+			Expect(notif.URN).To(Equal(urn))
+
+			var status ServiceStatusPayload
+			Expect(json.Unmarshal(notif.Payload, &status)).To(Succeed())
+			statuses = append(statuses, status.Status)
+		}
+		return statuses
+	}
+
+	g.BeforeEach(func() {
+		urn = URN{ID: "producer", Namespace: "ns1"}
+		eaaCtx = &Context{}
+		eaaCtx.serviceInfo = services{m: make(map[string]Service)}
+		eaaCtx.consumerConnections = consumerConns{m: make(map[string]ConsumerConnection)}
+		eaaCtx.subscriptionInfo = NotificationSubscriptions{
+			m: make(map[UniqueNotif]*ConsumerSubscription)}
+		eaaCtx.notificationBuffer = newNotificationStore(
+			NotificationBufferConfig{Size: 10})
+		eaaCtx.liveness = newServiceLiveness(ServiceLivenessConfig{
+			TTL:         util.Duration{Duration: 50 * time.Millisecond},
+			GracePeriod: util.Duration{Duration: 50 * time.Millisecond},
+		})
+
+		Expect(addSubscriptionToNamespace(consCN, "ns1",
+			[]NotificationDescriptor{{Name: "event", Version: "1.0.0"}},
+			eaaCtx)).To(Succeed())
+	})
+
+	g.It("keeps a refreshed service", func() {
+		register()
+		time.Sleep(30 * time.Millisecond)
+		register()
+		time.Sleep(30 * time.Millisecond)
+		eaaCtx.liveness.check(eaaCtx)
+
+		Expect(eaaCtx.serviceInfo.m[prodCN].Status).To(Equal("ready"))
+		Expect(receivedStatuses()).To(BeEmpty())
+	})
+
+	g.It("expires and then removes a service that is not refreshed", func() {
+		register()
+
+		time.Sleep(60 * time.Millisecond)
+		eaaCtx.liveness.check(eaaCtx)
+		Expect(eaaCtx.serviceInfo.m[prodCN].Status).To(Equal(serviceStatusExpired))
+		Expect(receivedStatuses()).To(Equal([]string{serviceStatusExpired}))
+
+		time.Sleep(50 * time.Millisecond)
+		eaaCtx.liveness.check(eaaCtx)
+		Expect(eaaCtx.serviceInfo.m).ToNot(HaveKey(prodCN))
+		Expect(receivedStatuses()).To(Equal([]string{serviceStatusExpired,
+			serviceStatusDeregistered}))
+	})
+
+	g.It("reports an expired service refreshed again as active", func() {
+		register()
+
+		time.Sleep(60 * time.Millisecond)
+		eaaCtx.liveness.check(eaaCtx)
+		register()
+
+		Expect(eaaCtx.serviceInfo.m[prodCN].Status).To(Equal("ready"))
+		Expect(receivedStatuses()).To(Equal([]string{serviceStatusExpired,
+			serviceStatusActive}))
+	})
+
+	g.It("watches services with the shortest TTL", func() {
+		eaaCtx.liveness.ttl = time.Nanosecond
+		ctx, cancel := context.WithTimeout(context.Background(),
+			10*time.Millisecond)
+		defer cancel()
+		Expect(func() { eaaCtx.liveness.watch(ctx, eaaCtx) }).ToNot(Panic())
+	})
+
+	g.It("rejects TTL shorter than the minimum", func() {
+		cfg := Config{ServiceLiveness: ServiceLivenessConfig{
+			TTL: util.Duration{Duration: time.Nanosecond}}}
+		err := cfg.Validate()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring(
+			"ServiceLiveness.TTL: must be zero or at least 1s"))
+	})
+})
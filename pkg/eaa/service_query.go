@@ -0,0 +1,126 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package eaa
+
+import (
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// serviceQuery selects services returned by GetServices. The zero value
+// selects all services.
+type serviceQuery struct {
+	// namespace of services, any if empty
+	namespace string
+	// urnPrefix is the prefix of "namespace:id" URN of services
+	urnPrefix string
+	// tags services must all have
+	tags []string
+	// limit is the maximum number of services returned, all if 0
+	limit int
+	// pageToken is the URN of the last service of the previous page
+	pageToken string
+}
+
+// parseServiceQuery parses the GetServices query parameters: namespace,
+// urn_prefix, tag (repeated), limit and page_token
+func parseServiceQuery(values url.Values) (serviceQuery, error) {
+	q := serviceQuery{
+		namespace: values.Get("namespace"),
+		urnPrefix: values.Get("urn_prefix"),
+		tags:      values["tag"],
+		pageToken: values.Get("page_token"),
+	}
+
+	if limit := values.Get("limit"); limit != "" {
+		var err error
+		if q.limit, err = strconv.Atoi(limit); err != nil || q.limit < 0 {
+			return q, errors.Errorf("invalid limit %q", limit)
+		}
+	}
+	return q, nil
+}
+
+// matches checks if the service is selected by the query
+func (q *serviceQuery) matches(serv *Service) bool {
+	if serv.URN == nil {
+		return q.namespace == "" && q.urnPrefix == "" && len(q.tags) == 0
+	}
+	if q.namespace != "" && serv.URN.Namespace != q.namespace {
+		return false
+	}
+	if !strings.HasPrefix(serv.URN.String(), q.urnPrefix) {
+		return false
+	}
+	for _, tag := range q.tags {
+		found := false
+		for _, servTag := range serv.Tags {
+			if servTag == tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// queryServices returns a page of services, including services of
+// federation peers, selected by the query and visible to the client,
+// ordered by URN. The caller has to hold the serviceInfo lock.
+func queryServices(commonName string, q serviceQuery,
+	eaaCtx *Context) ServiceList {
+	var (
+		list ServiceList
+		urns []string
+	)
+
+	selected := make(map[string]Service)
+	add := func(serv Service) {
+		if !q.matches(&serv) {
+			return
+		}
+
+		urn := ""
+		if serv.URN != nil {
+			urn = serv.URN.String()
+			if !eaaCtx.cfg.AccessControl.allowed(commonName,
+				accessActionDiscover, urn) {
+				return
+			}
+		}
+		// The same service may run on several nodes
+		if serv.Node != "" {
+			urn += "@" + serv.Node
+		}
+		if q.pageToken != "" && urn <= q.pageToken {
+			return
+		}
+		selected[urn] = serv
+		urns = append(urns, urn)
+	}
+
+	for _, serv := range eaaCtx.serviceInfo.m {
+		add(serv)
+	}
+	for _, serv := range eaaCtx.federation.services() {
+		add(serv)
+	}
+	sort.Strings(urns)
+
+	if q.limit > 0 && len(urns) > q.limit {
+		urns = urns[:q.limit]
+		list.NextPageToken = urns[q.limit-1]
+	}
+	for _, urn := range urns {
+		list.Services = append(list.Services, selected[urn])
+	}
+	return list
+}
@@ -0,0 +1,84 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package eaa
+
+import (
+	"net/url"
+
+	g "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = g.Describe("queryServices", func() {
+	var eaaCtx *Context
+
+	urns := func(list ServiceList) []string {
+		var result []string
+		for _, serv := range list.Services {
+			result = append(result, serv.URN.String())
+		}
+		return result
+	}
+
+	g.BeforeEach(func() {
+		eaaCtx = &Context{}
+		eaaCtx.serviceInfo = services{m: make(map[string]Service)}
+		for _, serv := range []struct {
+			namespace, id string
+			tags          []string
+		}{
+			{"ns1", "camera-2", []string{"video"}},
+			{"ns1", "camera-1", []string{"video", "4k"}},
+			{"ns1", "sensor", nil},
+			{"ns2", "camera-1", []string{"video"}},
+		} {
+			urn := URN{Namespace: serv.namespace, ID: serv.id}
+			eaaCtx.serviceInfo.m[urn.String()] = Service{URN: &urn,
+				Tags: serv.tags}
+		}
+	})
+
+	g.It("returns all services ordered by URN", func() {
+		Expect(urns(queryServices("ns:app", serviceQuery{}, eaaCtx))).To(
+			Equal([]string{"ns1:camera-1", "ns1:camera-2", "ns1:sensor",
+				"ns2:camera-1"}))
+	})
+
+	g.It("filters services", func() {
+		Expect(urns(queryServices("ns:app", serviceQuery{namespace: "ns2"},
+			eaaCtx))).To(Equal([]string{"ns2:camera-1"}))
+		Expect(urns(queryServices("ns:app",
+			serviceQuery{urnPrefix: "ns1:camera"}, eaaCtx))).To(
+			Equal([]string{"ns1:camera-1", "ns1:camera-2"}))
+		Expect(urns(queryServices("ns:app",
+			serviceQuery{tags: []string{"video", "4k"}}, eaaCtx))).To(
+			Equal([]string{"ns1:camera-1"}))
+	})
+
+	g.It("pages services", func() {
+		query := serviceQuery{limit: 3}
+		list := queryServices("ns:app", query, eaaCtx)
+		Expect(urns(list)).To(Equal([]string{"ns1:camera-1", "ns1:camera-2",
+			"ns1:sensor"}))
+		Expect(list.NextPageToken).To(Equal("ns1:sensor"))
+
+		query.pageToken = list.NextPageToken
+		list = queryServices("ns:app", query, eaaCtx)
+		Expect(urns(list)).To(Equal([]string{"ns2:camera-1"}))
+		Expect(list.NextPageToken).To(BeEmpty())
+	})
+
+	g.It("parses query parameters", func() {
+		values, err := url.ParseQuery(
+			"namespace=ns1&tag=video&tag=4k&limit=10&page_token=ns1:a")
+		Expect(err).ShouldNot(HaveOccurred())
+		query, err := parseServiceQuery(values)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(query).To(Equal(serviceQuery{namespace: "ns1",
+			tags: []string{"video", "4k"}, limit: 10, pageToken: "ns1:a"}))
+
+		_, err = parseServiceQuery(url.Values{"limit": {"-1"}})
+		Expect(err).To(HaveOccurred())
+	})
+})
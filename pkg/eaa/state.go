@@ -0,0 +1,261 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package eaa
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// serviceStatusStale is the status of a service restored from the state
+// file until its producer contacts EAA again
+const serviceStatusStale = "stale"
+
+// persistedSubscription is a subscription of a consumer kept in the state
+// file
+type persistedSubscription struct {
+	ClientCommonName string       `json:"client"`
+	Scope            string       `json:"scope"`
+	Subscription     Subscription `json:"subscription"`
+}
+
+// persistedState is the content of the state file
+type persistedState struct {
+	Services      []Service               `json:"services"`
+	Subscriptions []persistedSubscription `json:"subscriptions"`
+}
+
+// stateStore keeps registered services and subscriptions in a file, so
+// that they survive a restart of EAA. A nil store keeps nothing.
+type stateStore struct {
+	sync.Mutex
+	path string
+
+	// original status of services restored as stale, by Common Name
+	stale map[string]string
+}
+
+func newStateStore(path string) *stateStore {
+	return &stateStore{
+		path:  path,
+		stale: make(map[string]string),
+	}
+}
+
+// serviceUpdated is called for every handled service message, a service
+// registered by its producer or deregistered is no longer stale
+func (s *stateStore) serviceUpdated(commonName string, svcMsg *ServiceMessage) {
+	if s == nil {
+		return
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	if svcMsg.Action != serviceActionRegister ||
+		svcMsg.Svc.Status != serviceStatusStale {
+		delete(s.stale, commonName)
+	}
+}
+
+// save writes the current services and subscriptions to the state file,
+// errors are logged only as the state is kept in memory anyway
+func (s *stateStore) save(eaaCtx *Context) {
+	if s == nil {
+		return
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	state := snapshotState(eaaCtx)
+	for i, serv := range state.Services {
+		if status, ok := s.stale[serv.URN.String()]; ok {
+			state.Services[i].Status = status
+		}
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		log.Errf("Failed to marshal EAA state: %v", err)
+		return
+	}
+
+	// Write to a temporary file first, so that a crash can't leave the state
+	// file truncated
+	tmpPath := s.path + ".tmp"
+	if err = ioutil.WriteFile(tmpPath, data, 0600); err != nil {
+		log.Errf("Failed to write EAA state: %v", err)
+		return
+	}
+	if err = os.Rename(tmpPath, s.path); err != nil {
+		log.Errf("Failed to write EAA state: %v", err)
+	}
+}
+
+// snapshotState returns the registered services and subscriptions sorted,
+// so that unchanged state gives the same file
+func snapshotState(eaaCtx *Context) persistedState {
+	var state persistedState
+
+	eaaCtx.serviceInfo.RLock()
+	for _, serv := range eaaCtx.serviceInfo.m {
+		if serv.URN != nil {
+			state.Services = append(state.Services, serv)
+		}
+	}
+	eaaCtx.serviceInfo.RUnlock()
+
+	sort.Slice(state.Services, func(i, j int) bool {
+		return state.Services[i].URN.String() < state.Services[j].URN.String()
+	})
+
+	type subKey struct {
+		client    string
+		namespace string
+		serviceID string
+	}
+	subs := make(map[subKey][]NotificationDescriptor)
+
+	eaaCtx.subscriptionInfo.RLock()
+	for key, conSub := range eaaCtx.subscriptionInfo.m {
+		for _, client := range conSub.namespaceSubscriptions {
+			k := subKey{client, key.namespace, ""}
+			subs[k] = append(subs[k], conSub.notification)
+		}
+		for serviceID, clients := range conSub.serviceSubscriptions {
+			for _, client := range clients {
+				k := subKey{client, key.namespace, serviceID}
+				subs[k] = append(subs[k], conSub.notification)
+			}
+		}
+	}
+	eaaCtx.subscriptionInfo.RUnlock()
+
+	for k, notifs := range subs {
+		scope := subscriptionScopeNamespace
+		if k.serviceID != "" {
+			scope = subscriptionScopeService
+		}
+		sort.Slice(notifs, func(i, j int) bool {
+			if notifs[i].Name != notifs[j].Name {
+				return notifs[i].Name < notifs[j].Name
+			}
+			return notifs[i].Version < notifs[j].Version
+		})
+		state.Subscriptions = append(state.Subscriptions, persistedSubscription{
+			ClientCommonName: k.client,
+			Scope:            scope,
+			Subscription: Subscription{
+				URN:           &URN{ID: k.serviceID, Namespace: k.namespace},
+				Notifications: notifs,
+			},
+		})
+	}
+
+	sort.Slice(state.Subscriptions, func(i, j int) bool {
+		a, b := state.Subscriptions[i], state.Subscriptions[j]
+		if a.ClientCommonName != b.ClientCommonName {
+			return a.ClientCommonName < b.ClientCommonName
+		}
+		return a.Subscription.URN.String() < b.Subscription.URN.String()
+	})
+
+	return state
+}
+
+// restoreState registers services and subscriptions from the state file
+// through the Message Broker. Services are marked stale until their
+// producers contact EAA again.
+func restoreState(eaaCtx *Context) error {
+	s := eaaCtx.state
+	if s == nil {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(filepath.Clean(s.path))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "Failed to read EAA state")
+	}
+
+	var state persistedState
+	if err = json.Unmarshal(data, &state); err != nil {
+		return errors.Wrapf(err, "Failed to decode EAA state from %s", s.path)
+	}
+
+	for i := range state.Services {
+		serv := state.Services[i]
+		if serv.URN == nil {
+			continue
+		}
+		commonName := serv.URN.String()
+
+		s.Lock()
+		s.stale[commonName] = serv.Status
+		s.Unlock()
+
+		serv.Status = serviceStatusStale
+		err = publishServiceMessage(commonName, &serv, serviceActionRegister,
+			eaaCtx)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to restore service %s",
+				commonName)
+		}
+	}
+
+	for _, sub := range state.Subscriptions {
+		err = processSubscriptionRequest(subscriptionActionSubscribe, sub.Scope,
+			sub.ClientCommonName, sub.Subscription.URN,
+			sub.Subscription.Notifications, nil, eaaCtx)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to restore subscription of %s",
+				sub.ClientCommonName)
+		}
+	}
+
+	log.Infof("Restored %d services and %d subscriptions from %s",
+		len(state.Services), len(state.Subscriptions), s.path)
+	return nil
+}
+
+// refreshStaleService registers again a service restored as stale with its
+// original status once its producer contacts EAA
+func refreshStaleService(commonName string, eaaCtx *Context) {
+	s := eaaCtx.state
+	if s == nil {
+		return
+	}
+
+	// The entry is removed once the registration is handled, see
+	// serviceUpdated
+	s.Lock()
+	status, ok := s.stale[commonName]
+	s.Unlock()
+	if !ok {
+		return
+	}
+
+	eaaCtx.serviceInfo.RLock()
+	serv, found := eaaCtx.serviceInfo.m[commonName]
+	eaaCtx.serviceInfo.RUnlock()
+	if !found || serv.Status != serviceStatusStale {
+		return
+	}
+
+	serv.Status = status
+	err := publishServiceMessage(commonName, &serv, serviceActionRegister,
+		eaaCtx)
+	if err != nil {
+		log.Errf("Failed to refresh stale service %s: %v", commonName, err)
+	}
+}
@@ -0,0 +1,101 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package eaa
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	g "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = g.Describe("EAA state persistence", func() {
+	const (
+		prodCN = "ns1:producer"
+		consCN = "ns2:consumer"
+	)
+
+	var (
+		dir       string
+		statePath string
+	)
+
+	notif := NotificationDescriptor{Name: "event", Version: "1.0.0"}
+
+	newContext := func() *Context {
+		eaaCtx := &Context{}
+		eaaCtx.serviceInfo = services{m: make(map[string]Service)}
+		eaaCtx.consumerConnections = consumerConns{m: make(map[string]ConsumerConnection)}
+		eaaCtx.subscriptionInfo = NotificationSubscriptions{
+			m: make(map[UniqueNotif]*ConsumerSubscription)}
+		eaaCtx.state = newStateStore(statePath)
+		return eaaCtx
+	}
+
+	g.BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "eaaState")
+		Expect(err).ShouldNot(HaveOccurred())
+		statePath = filepath.Join(dir, "state.json")
+	})
+
+	g.AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	g.It("restores services as stale and subscriptions after a restart", func() {
+		saved := newContext()
+		urn := URN{ID: "producer", Namespace: "ns1"}
+		Expect(addService(prodCN, Service{URN: &urn, Status: "ready"}, saved)).
+			To(Succeed())
+		Expect(addSubscriptionToService(consCN, "ns1", "producer",
+			[]NotificationDescriptor{notif}, saved)).To(Succeed())
+		saved.state.save(saved)
+
+		restored := newContext()
+		broker := NewGoChannelMsgBroker(restored)
+		restored.MsgBrokerCtx = broker
+		defer broker.removeAll()
+		Expect(broker.addPublisher(servicesPublisher, servicesTopic, nil)).To(Succeed())
+		Expect(broker.addSubscriber(servicesSubscriber, servicesTopic, nil)).To(Succeed())
+
+		Expect(restoreState(restored)).To(Succeed())
+
+		getStatus := func() string {
+			restored.serviceInfo.RLock()
+			defer restored.serviceInfo.RUnlock()
+			return restored.serviceInfo.m[prodCN].Status
+		}
+		Eventually(getStatus, time.Second).Should(Equal(serviceStatusStale))
+
+		Eventually(func() []Subscription {
+			subs, err := getConsumerSubscriptions(consCN, restored)
+			Expect(err).ShouldNot(HaveOccurred())
+			return subs.Subscriptions
+		}, time.Second).Should(ConsistOf(Subscription{
+			URN:           &URN{ID: "producer", Namespace: "ns1"},
+			Notifications: []NotificationDescriptor{notif},
+		}))
+
+		// The original status is kept in the file while the service is stale
+		Expect(ioutil.ReadFile(statePath)).To(ContainSubstring(`"ready"`))
+
+		refreshStaleService(prodCN, restored)
+		Eventually(getStatus, time.Second).Should(Equal("ready"))
+	})
+
+	g.It("starts empty without a state file", func() {
+		eaaCtx := newContext()
+		Expect(restoreState(eaaCtx)).To(Succeed())
+		Expect(eaaCtx.serviceInfo.m).To(BeEmpty())
+	})
+
+	g.It("fails on a corrupted state file", func() {
+		Expect(ioutil.WriteFile(statePath, []byte("{"), 0600)).To(Succeed())
+		Expect(restoreState(newContext())).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,90 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package eaa
+
+import (
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Subscriptions may use path.Match patterns as the service ID and as the
+// name and version of notifications, e.g. a namespace subscription to
+// {"name": "*", "version": "*"} gets all notifications of the namespace and
+// a subscription to service "sensor-*" gets notifications of all sensors
+// registered now or later. The namespace has to be given exactly as
+// notifications are routed through per-namespace topics.
+
+// isSubscriptionPattern checks if s contains path.Match metacharacters
+func isSubscriptionPattern(s string) bool {
+	return strings.ContainsAny(s, `*?[\`)
+}
+
+// matchesSubscription checks if the subscribed name or pattern matches the
+// name of a notification or service
+func matchesSubscription(subscribed, name string) bool {
+	return subscribed == name || matchPattern(subscribed, name)
+}
+
+// checkSubscriptionPatterns validates patterns of a subscription request
+func checkSubscriptionPatterns(urn *URN,
+	notifs []NotificationDescriptor) error {
+
+	if urn != nil {
+		if isSubscriptionPattern(urn.Namespace) {
+			return errors.Errorf("namespace %q can't be a pattern",
+				urn.Namespace)
+		}
+		if _, err := path.Match(urn.ID, ""); err != nil {
+			return errors.Wrapf(err, "invalid service ID pattern %q", urn.ID)
+		}
+	}
+
+	for _, n := range notifs {
+		if _, err := path.Match(n.Name, ""); err != nil {
+			return errors.Wrapf(err, "invalid notification name pattern %q",
+				n.Name)
+		}
+		if _, err := path.Match(n.Version, ""); err != nil {
+			return errors.Wrapf(err,
+				"invalid notification version pattern %q", n.Version)
+		}
+	}
+	return nil
+}
+
+// subscribersOf returns consumers subscribed to the notification of the
+// producer, directly or with patterns. The caller has to hold the
+// subscriptionInfo lock.
+func subscribersOf(prodURN URN, notif *NotificationFromProducer,
+	eaaCtx *Context) []string {
+
+	var subscribers []string
+	added := make(map[string]bool)
+	add := func(ids SubscriberIds) {
+		for _, id := range ids {
+			if !added[id] {
+				added[id] = true
+				subscribers = append(subscribers, id)
+			}
+		}
+	}
+
+	for key, consumerSub := range eaaCtx.subscriptionInfo.m {
+		if key.namespace != prodURN.Namespace ||
+			!matchesSubscription(key.notifName, notif.Name) ||
+			!matchesSubscription(key.notifVersion, notif.Version) {
+			continue
+		}
+
+		add(consumerSub.namespaceSubscriptions)
+		for serviceID, ids := range consumerSub.serviceSubscriptions {
+			if matchesSubscription(serviceID, prodURN.ID) {
+				add(ids)
+			}
+		}
+	}
+	return subscribers
+}
@@ -0,0 +1,80 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package eaa
+
+import (
+	g "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = g.Describe("subscription patterns", func() {
+	var eaaCtx *Context
+
+	g.BeforeEach(func() {
+		eaaCtx = &Context{}
+		eaaCtx.subscriptionInfo = NotificationSubscriptions{
+			m: make(map[UniqueNotif]*ConsumerSubscription)}
+	})
+
+	subscribers := func(id, name, version string) []string {
+		return subscribersOf(URN{Namespace: "ns", ID: id},
+			&NotificationFromProducer{Name: name, Version: version}, eaaCtx)
+	}
+
+	g.It("matches all notifications of a namespace", func() {
+		Expect(addSubscriptionToNamespace("ns:dashboard", "ns",
+			[]NotificationDescriptor{{Name: "*", Version: "*"}},
+			eaaCtx)).To(Succeed())
+
+		Expect(subscribers("sensor-1", "temperature", "1.0.0")).To(
+			ConsistOf("ns:dashboard"))
+		Expect(subscribers("camera", "motion", "2.0.0")).To(
+			ConsistOf("ns:dashboard"))
+		Expect(subscribersOf(URN{Namespace: "other", ID: "sensor-1"},
+			&NotificationFromProducer{Name: "temperature", Version: "1.0.0"},
+			eaaCtx)).To(BeEmpty())
+	})
+
+	g.It("matches services and versions by pattern", func() {
+		Expect(addSubscriptionToService("ns:aggregator", "ns", "sensor-*",
+			[]NotificationDescriptor{{Name: "temperature", Version: "1.*"}},
+			eaaCtx)).To(Succeed())
+		Expect(addSubscriptionToService("ns:display", "ns", "sensor-1",
+			[]NotificationDescriptor{{Name: "temperature", Version: "1.0.0"}},
+			eaaCtx)).To(Succeed())
+
+		Expect(subscribers("sensor-1", "temperature", "1.0.0")).To(
+			ConsistOf("ns:aggregator", "ns:display"))
+		Expect(subscribers("sensor-2", "temperature", "1.2.0")).To(
+			ConsistOf("ns:aggregator"))
+		Expect(subscribers("sensor-2", "temperature", "2.0.0")).To(BeEmpty())
+		Expect(subscribers("camera", "temperature", "1.0.0")).To(BeEmpty())
+	})
+
+	g.It("returns a consumer subscribed several times once", func() {
+		Expect(addSubscriptionToNamespace("ns:dashboard", "ns",
+			[]NotificationDescriptor{{Name: "*", Version: "*"},
+				{Name: "temperature", Version: "1.0.0"}},
+			eaaCtx)).To(Succeed())
+		Expect(addSubscriptionToService("ns:dashboard", "ns", "*",
+			[]NotificationDescriptor{{Name: "temperature", Version: "*"}},
+			eaaCtx)).To(Succeed())
+
+		Expect(subscribers("sensor-1", "temperature", "1.0.0")).To(
+			Equal([]string{"ns:dashboard"}))
+	})
+
+	g.It("rejects invalid patterns", func() {
+		Expect(checkSubscriptionPatterns(&URN{Namespace: "ns", ID: "sensor-*"},
+			[]NotificationDescriptor{{Name: "*", Version: "1.?.0"}})).To(
+			Succeed())
+		Expect(checkSubscriptionPatterns(&URN{Namespace: "n*"},
+			nil)).ToNot(Succeed())
+		Expect(checkSubscriptionPatterns(&URN{Namespace: "ns", ID: "[a"},
+			nil)).ToNot(Succeed())
+		Expect(checkSubscriptionPatterns(&URN{Namespace: "ns"},
+			[]NotificationDescriptor{{Name: "[", Version: "*"}})).ToNot(
+			Succeed())
+	})
+})
@@ -0,0 +1,515 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package eaa
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	_ "crypto/sha256" // registers SHA-256 used by RS256 and ES256
+	_ "crypto/sha512" // registers SHA-384 and SHA-512
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultTokenIdentityClaim   = "sub"
+	defaultJWKSRefreshInterval  = time.Hour
+	jwksMinRefreshInterval      = time.Minute
+	jwksFetchTimeout            = 10 * time.Second
+	maxJWKSSize                 = 1024 * 1024
+	tokenClockSkew              = time.Minute
+	clientCommonNameKey         = contextKey("client-common-name")
+	bearerAuthorizationPrefix   = "bearer "
+	authorizationHeader         = "Authorization"
+	authorizationMetadataHeader = "authorization"
+)
+
+// tokenAlgorithm describes a supported JWS signature algorithm, curve is
+// nil for RSA algorithms
+type tokenAlgorithm struct {
+	hash  crypto.Hash
+	curve elliptic.Curve
+}
+
+var tokenAlgorithms = map[string]tokenAlgorithm{
+	"RS256": {crypto.SHA256, nil},
+	"RS384": {crypto.SHA384, nil},
+	"RS512": {crypto.SHA512, nil},
+	"ES256": {crypto.SHA256, elliptic.P256()},
+	"ES384": {crypto.SHA384, elliptic.P384()},
+	"ES512": {crypto.SHA512, elliptic.P521()},
+}
+
+// jsonWebKey is a public key of a JWKS (RFC 7517)
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// verificationKey is a key of the JWKS, alg is empty if the key may be
+// used with any algorithm of its type
+type verificationKey struct {
+	key crypto.PublicKey
+	alg string
+}
+
+// tokenAuthenticator authenticates applications with JWT bearer tokens,
+// see TokenAuthConfig
+type tokenAuthenticator struct {
+	cfg             TokenAuthConfig
+	client          *http.Client
+	refreshInterval time.Duration
+	minRefresh      time.Duration
+
+	sync.Mutex
+	keys      map[string]verificationKey
+	fetchedAt time.Time
+	// fetching is closed when the fetch in flight ends, nil if there's none
+	fetching chan struct{}
+}
+
+func newTokenAuthenticator(cfg TokenAuthConfig) *tokenAuthenticator {
+	a := &tokenAuthenticator{
+		cfg:             cfg,
+		client:          &http.Client{Timeout: jwksFetchTimeout},
+		refreshInterval: cfg.JWKSRefreshInterval.Duration,
+		minRefresh:      jwksMinRefreshInterval,
+	}
+	if a.cfg.IdentityClaim == "" {
+		a.cfg.IdentityClaim = defaultTokenIdentityClaim
+	}
+	if a.refreshInterval == 0 {
+		a.refreshInterval = defaultJWKSRefreshInterval
+	}
+	return a
+}
+
+// clientAuth returns the client certificate policy of the TLS servers,
+// certificates become optional when tokens are accepted
+func (a *tokenAuthenticator) clientAuth() tls.ClientAuthType {
+	if a == nil {
+		return tls.RequireAndVerifyClientCert
+	}
+	return tls.VerifyClientCertIfGiven
+}
+
+// authenticate validates the token and returns the identity of the
+// application in the Common Name format "namespace:id"
+func (a *tokenAuthenticator) authenticate(token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", errors.New("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeTokenPart(parts[0], &header); err != nil {
+		return "", errors.Wrap(err, "invalid token header")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", errors.Wrap(err, "invalid token signature")
+	}
+	key, err := a.key(header.Kid)
+	if err != nil {
+		return "", err
+	}
+	if key.alg != "" && key.alg != header.Alg {
+		return "", errors.Errorf("key %q is not for %s", header.Kid,
+			header.Alg)
+	}
+	if err = verifyTokenSignature(header.Alg, key.key,
+		[]byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return "", err
+	}
+
+	var claims map[string]interface{}
+	if err = decodeTokenPart(parts[1], &claims); err != nil {
+		return "", errors.Wrap(err, "invalid token claims")
+	}
+	if err = a.checkClaims(claims, time.Now()); err != nil {
+		return "", err
+	}
+
+	identity, _ := claims[a.cfg.IdentityClaim].(string)
+	if _, err = CommonNameStringToURN(identity); err != nil {
+		return "", errors.Wrapf(err, "invalid identity claim %s",
+			a.cfg.IdentityClaim)
+	}
+	return identity, nil
+}
+
+// checkClaims checks validity period, issuer and audience of the token
+func (a *tokenAuthenticator) checkClaims(claims map[string]interface{},
+	now time.Time) error {
+
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return errors.New("token has no expiration")
+	}
+	if now.Add(-tokenClockSkew).After(time.Unix(int64(exp), 0)) {
+		return errors.New("token is expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok &&
+		now.Add(tokenClockSkew).Before(time.Unix(int64(nbf), 0)) {
+		return errors.New("token is not valid yet")
+	}
+	if iss, _ := claims["iss"].(string); iss != a.cfg.Issuer {
+		return errors.Errorf("unexpected token issuer %q", iss)
+	}
+
+	if a.cfg.Audience == "" {
+		return nil
+	}
+	switch aud := claims["aud"].(type) {
+	case string:
+		if aud == a.cfg.Audience {
+			return nil
+		}
+	case []interface{}:
+		for _, v := range aud {
+			if v == a.cfg.Audience {
+				return nil
+			}
+		}
+	}
+	return errors.New("token is not issued for EAA")
+}
+
+// key returns the key with kid, the only key of the JWKS if kid is empty.
+// Keys are fetched again when they're older than the refresh interval or
+// kid is unknown, but not more often than minRefresh. The fetch runs
+// without the lock, concurrent callers wait for the fetch in flight.
+func (a *tokenAuthenticator) key(kid string) (verificationKey, error) {
+	a.Lock()
+	defer a.Unlock()
+
+	lookup := func() (verificationKey, bool) {
+		if kid == "" && len(a.keys) == 1 {
+			for _, key := range a.keys {
+				return key, true
+			}
+		}
+		key, ok := a.keys[kid]
+		return key, ok
+	}
+
+	key, ok := lookup()
+	sinceFetch := time.Since(a.fetchedAt)
+	if (!ok || sinceFetch > a.refreshInterval) && sinceFetch >= a.minRefresh {
+		if fetching := a.fetching; fetching != nil {
+			a.Unlock()
+			<-fetching
+			a.Lock()
+		} else {
+			fetching = make(chan struct{})
+			a.fetching = fetching
+			a.Unlock()
+			keys, err := a.fetchKeys()
+			a.Lock()
+
+			// A failed fetch keeps the previously fetched keys
+			if err != nil {
+				log.Errf("Failed to fetch JWKS from %s: %v", a.cfg.JWKSURL,
+					err)
+			} else {
+				a.keys = keys
+			}
+			a.fetchedAt = time.Now()
+			a.fetching = nil
+			close(fetching)
+		}
+		key, ok = lookup()
+	}
+	if !ok {
+		return verificationKey{}, errors.Errorf("unknown token key %q", kid)
+	}
+	return key, nil
+}
+
+// fetchKeys fetches the JWKS and returns its signature keys
+func (a *tokenAuthenticator) fetchKeys() (map[string]verificationKey,
+	error) {
+
+	resp, err := a.client.Get(a.cfg.JWKSURL)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err1 := resp.Body.Close(); err1 != nil {
+			log.Errf("Failed to close JWKS response body: %v", err1)
+		}
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected status %s", resp.Status)
+	}
+
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	err = json.NewDecoder(io.LimitReader(resp.Body, maxJWKSSize)).Decode(
+		&jwks)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode JWKS")
+	}
+
+	keys := make(map[string]verificationKey)
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			log.Warningf("Skipped JWKS key %q: %v", jwk.Kid, err)
+			continue
+		}
+		keys[jwk.Kid] = verificationKey{key: key, alg: jwk.Alg}
+	}
+	log.Infof("Fetched %d token keys from %s", len(keys), a.cfg.JWKSURL)
+	return keys, nil
+}
+
+// publicKey decodes the RSA or EC public key
+func (k *jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	decode := func(s string) (*big.Int, error) {
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		if err != nil || len(b) == 0 {
+			return nil, errors.New("invalid key parameter")
+		}
+		return new(big.Int).SetBytes(b), nil
+	}
+
+	switch k.Kty {
+	case "RSA":
+		n, err := decode(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, errors.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on the curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, errors.Errorf("unsupported key type %q", k.Kty)
+}
+
+// verifyTokenSignature verifies the JWS signature of signed with alg
+func verifyTokenSignature(alg string, key crypto.PublicKey, signed,
+	signature []byte) error {
+
+	algorithm, ok := tokenAlgorithms[alg]
+	if !ok {
+		return errors.Errorf("unsupported token algorithm %q", alg)
+	}
+	h := algorithm.hash.New()
+	_, _ = h.Write(signed)
+	digest := h.Sum(nil)
+
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if algorithm.curve == nil &&
+			rsa.VerifyPKCS1v15(k, algorithm.hash, digest, signature) == nil {
+			return nil
+		}
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if algorithm.curve == k.Curve && len(signature) == 2*size &&
+			ecdsa.Verify(k, digest,
+				new(big.Int).SetBytes(signature[:size]),
+				new(big.Int).SetBytes(signature[size:])) {
+			return nil
+		}
+	}
+	return errors.New("invalid token signature")
+}
+
+// decodeTokenPart decodes a base64url encoded JSON part of the token
+func decodeTokenPart(part string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// bearerToken returns the token of the bearer authorization value
+func bearerToken(authorization string) string {
+	if len(authorization) <= len(bearerAuthorizationPrefix) ||
+		!strings.EqualFold(authorization[:len(bearerAuthorizationPrefix)],
+			bearerAuthorizationPrefix) {
+		return ""
+	}
+	return strings.TrimSpace(authorization[len(bearerAuthorizationPrefix):])
+}
+
+// clientCommonName returns the identity of the client of the request, the
+// Common Name of its certificate or the identity from its bearer token
+func clientCommonName(r *http.Request) string {
+	if commonName, ok := r.Context().Value(
+		clientCommonNameKey).(string); ok {
+		return commonName
+	}
+	if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+		return r.TLS.PeerCertificates[0].Subject.CommonName
+	}
+	return ""
+}
+
+// tokenAuthMiddleware authenticates clients without a certificate with
+// their bearer token
+func tokenAuthMiddleware(eaaCtx *Context) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if eaaCtx.tokenAuth == nil || clientCommonName(r) != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			token := bearerToken(r.Header.Get(authorizationHeader))
+			if token == "" {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				writeError(w, r, http.StatusUnauthorized,
+					ErrorCodeUnauthorized,
+					"client certificate or bearer token required")
+				auditRequest(eaaCtx, r, "", http.StatusUnauthorized)
+				return
+			}
+			commonName, err := eaaCtx.tokenAuth.authenticate(token)
+			if err != nil {
+				log.Infof("Rejected bearer token: %v", err)
+				w.Header().Set("WWW-Authenticate",
+					`Bearer error="invalid_token"`)
+				writeError(w, r, http.StatusUnauthorized,
+					ErrorCodeUnauthorized, "invalid bearer token")
+				auditRequest(eaaCtx, r, "", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(),
+				clientCommonNameKey, commonName)))
+		})
+	}
+}
+
+// authenticateGrpcToken authenticates a gRPC client without a certificate
+// with the bearer token in its metadata and returns the context with its
+// identity
+func authenticateGrpcToken(ctx context.Context,
+	eaaCtx *Context) (context.Context, error) {
+
+	if eaaCtx.tokenAuth == nil {
+		return ctx, nil
+	}
+	if _, err := commonNameFromContext(ctx); err == nil {
+		return ctx, nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	var token string
+	if values := md.Get(authorizationMetadataHeader); len(values) > 0 {
+		token = bearerToken(values[0])
+	}
+	if token == "" {
+		return nil, status.Error(codes.Unauthenticated,
+			"client certificate or bearer token required")
+	}
+	commonName, err := eaaCtx.tokenAuth.authenticate(token)
+	if err != nil {
+		log.Infof("Rejected bearer token: %v", err)
+		return nil, status.Error(codes.Unauthenticated,
+			"invalid bearer token")
+	}
+	return context.WithValue(ctx, clientCommonNameKey, commonName), nil
+}
+
+// unaryTokenAuthenticator returns an interceptor authenticating clients
+// with bearer tokens, see authenticateGrpcToken
+func unaryTokenAuthenticator(eaaCtx *Context) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{},
+		info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		authCtx, err := authenticateGrpcToken(ctx, eaaCtx)
+		if err != nil {
+			auditCall(ctx, eaaCtx, req, info.FullMethod, "", err)
+			return nil, err
+		}
+		return handler(authCtx, req)
+	}
+}
+
+// authenticatedStream is a server stream with the context of the
+// authenticated client
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+// streamTokenAuthenticator is the streaming counterpart of
+// unaryTokenAuthenticator
+func streamTokenAuthenticator(eaaCtx *Context) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream,
+		_ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticateGrpcToken(ss.Context(), eaaCtx)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+	}
+}
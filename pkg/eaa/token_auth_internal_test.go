@@ -0,0 +1,270 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package eaa
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/mux"
+	g "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var _ = g.Describe("tokenAuthenticator", func() {
+	const (
+		issuer   = "https://issuer.example.com"
+		audience = "eaa"
+		client   = "ns:app"
+	)
+
+	var (
+		rsaKey  *rsa.PrivateKey
+		ecKey   *ecdsa.PrivateKey
+		jwks    []map[string]string
+		fetches int32
+		release chan struct{}
+		server  *httptest.Server
+		auth    *tokenAuthenticator
+	)
+
+	encode := func(b []byte) string {
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+
+	sign := func(alg, kid string, claims map[string]interface{}) string {
+		header, err := json.Marshal(map[string]string{"alg": alg, "kid": kid})
+		Expect(err).ShouldNot(HaveOccurred())
+		payload, err := json.Marshal(claims)
+		Expect(err).ShouldNot(HaveOccurred())
+		signed := encode(header) + "." + encode(payload)
+
+		var sig []byte
+		switch alg {
+		case "RS256":
+			digest := crypto.SHA256.New()
+			digest.Write([]byte(signed))
+			sig, err = rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256,
+				digest.Sum(nil))
+			Expect(err).ShouldNot(HaveOccurred())
+		case "ES256":
+			digest := crypto.SHA256.New()
+			digest.Write([]byte(signed))
+			r, s, err := ecdsa.Sign(rand.Reader, ecKey, digest.Sum(nil))
+			Expect(err).ShouldNot(HaveOccurred())
+			sig = make([]byte, 64)
+			r.FillBytes(sig[:32])
+			s.FillBytes(sig[32:])
+		}
+		return signed + "." + encode(sig)
+	}
+
+	claims := func() map[string]interface{} {
+		return map[string]interface{}{
+			"iss": issuer,
+			"aud": []string{"other", audience},
+			"sub": client,
+			"exp": time.Now().Add(time.Hour).Unix(),
+		}
+	}
+
+	g.BeforeEach(func() {
+		var err error
+		rsaKey, err = rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).ShouldNot(HaveOccurred())
+		ecKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		jwks = []map[string]string{
+			{"kty": "RSA", "kid": "rsa", "use": "sig",
+				"n": encode(rsaKey.N.Bytes()),
+				"e": encode(big.NewInt(int64(rsaKey.E)).Bytes())},
+			{"kty": "EC", "kid": "ec", "alg": "ES256", "crv": "P-256",
+				"x": encode(ecKey.X.Bytes()), "y": encode(ecKey.Y.Bytes())},
+		}
+		atomic.StoreInt32(&fetches, 0)
+		release = nil
+		server = httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&fetches, 1)
+				if release != nil {
+					<-release
+				}
+				json.NewEncoder(w).Encode(map[string]interface{}{"keys": jwks})
+			}))
+
+		auth = newTokenAuthenticator(TokenAuthConfig{
+			Issuer:   issuer,
+			Audience: audience,
+			JWKSURL:  server.URL,
+		})
+	})
+
+	g.AfterEach(func() {
+		server.Close()
+	})
+
+	g.It("accepts valid tokens", func() {
+		for _, alg := range []string{"RS256", "ES256"} {
+			kid := map[string]string{"RS256": "rsa", "ES256": "ec"}[alg]
+			identity, err := auth.authenticate(sign(alg, kid, claims()))
+			Expect(err).ShouldNot(HaveOccurred(), alg)
+			Expect(identity).To(Equal(client))
+		}
+		Expect(atomic.LoadInt32(&fetches)).To(BeEquivalentTo(1))
+	})
+
+	g.It("rejects invalid tokens", func() {
+		expired := claims()
+		expired["exp"] = time.Now().Add(-time.Hour).Unix()
+		otherIssuer := claims()
+		otherIssuer["iss"] = "https://other.example.com"
+		otherAudience := claims()
+		otherAudience["aud"] = "other"
+		noIdentity := claims()
+		noIdentity["sub"] = "app"
+		valid := sign("RS256", "rsa", claims())
+
+		for name, token := range map[string]string{
+			"expired":        sign("RS256", "rsa", expired),
+			"other issuer":   sign("RS256", "rsa", otherIssuer),
+			"other audience": sign("RS256", "rsa", otherAudience),
+			"no identity":    sign("RS256", "rsa", noIdentity),
+			"wrong key":      sign("ES256", "rsa", claims()),
+			"wrong alg":      sign("RS256", "ec", claims()),
+			"none alg":       sign("none", "rsa", claims()),
+			"bad signature":  valid[:len(valid)-4] + "AAAA",
+			"malformed":      "token",
+		} {
+			_, err := auth.authenticate(token)
+			Expect(err).Should(HaveOccurred(), name)
+		}
+	})
+
+	g.It("fetches keys again for an unknown key", func() {
+		auth.minRefresh = 0
+		_, err := auth.authenticate(sign("RS256", "rsa", claims()))
+		Expect(err).ShouldNot(HaveOccurred())
+
+		jwks[0]["kid"] = "rotated"
+		_, err = auth.authenticate(sign("RS256", "rotated", claims()))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(atomic.LoadInt32(&fetches)).To(BeEquivalentTo(2))
+	})
+
+	g.It("fetches keys once for concurrent requests", func() {
+		release = make(chan struct{})
+		token := sign("RS256", "rsa", claims())
+		errs := make(chan error, 5)
+		for i := 0; i < cap(errs); i++ {
+			go func() {
+				_, err := auth.authenticate(token)
+				errs <- err
+			}()
+		}
+		Eventually(func() int32 {
+			return atomic.LoadInt32(&fetches)
+		}).Should(BeEquivalentTo(1))
+
+		// The lock is free while the keys are fetched
+		locked := make(chan struct{})
+		go func() {
+			auth.Lock()
+			auth.Unlock()
+			close(locked)
+		}()
+		Eventually(locked).Should(BeClosed())
+
+		close(release)
+		for i := 0; i < cap(errs); i++ {
+			Eventually(errs).Should(Receive(BeNil()))
+		}
+		Expect(atomic.LoadInt32(&fetches)).To(BeEquivalentTo(1))
+	})
+
+	g.It("authenticates REST clients without a certificate", func() {
+		eaaCtx := &Context{tokenAuth: auth}
+		router := mux.NewRouter()
+		router.Use(tokenAuthMiddleware(eaaCtx))
+		router.Path("/services").HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(clientCommonName(r)))
+			})
+
+		serve := func(authorization string,
+			state *tls.ConnectionState) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("GET", "/services", nil)
+			req.TLS = state
+			if authorization != "" {
+				req.Header.Set("Authorization", authorization)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			return rec
+		}
+
+		rec := serve("Bearer "+sign("ES256", "ec", claims()),
+			&tls.ConnectionState{})
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Body.String()).To(Equal(client))
+
+		rec = serve("", &tls.ConnectionState{PeerCertificates: []*x509.Certificate{
+			{Subject: pkix.Name{CommonName: "ns:cert"}}}})
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Body.String()).To(Equal("ns:cert"))
+
+		rec = serve("", &tls.ConnectionState{})
+		Expect(rec.Code).To(Equal(http.StatusUnauthorized))
+		Expect(rec.Header().Get("WWW-Authenticate")).To(Equal("Bearer"))
+
+		rec = serve("Bearer invalid", &tls.ConnectionState{})
+		Expect(rec.Code).To(Equal(http.StatusUnauthorized))
+		var resp ErrorResponse
+		Expect(json.Unmarshal(rec.Body.Bytes(), &resp)).To(Succeed())
+		Expect(resp.Code).To(Equal(ErrorCodeUnauthorized))
+	})
+
+	g.It("authenticates gRPC clients without a certificate", func() {
+		intercept := unaryTokenAuthenticator(&Context{tokenAuth: auth})
+		handler := func(ctx context.Context, _ interface{}) (interface{},
+			error) {
+			return commonNameFromContext(ctx)
+		}
+
+		ctx := metadata.NewIncomingContext(context.Background(),
+			metadata.Pairs("authorization",
+				"Bearer "+sign("RS256", "rsa", claims())))
+		identity, err := intercept(ctx, nil, &grpc.UnaryServerInfo{}, handler)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(identity).To(Equal(client))
+
+		_, err = intercept(context.Background(), nil, &grpc.UnaryServerInfo{},
+			handler)
+		Expect(status.Code(err)).To(Equal(codes.Unauthenticated))
+	})
+
+	g.It("requires client certificates when disabled", func() {
+		var disabled *tokenAuthenticator
+		Expect(disabled.clientAuth()).To(Equal(tls.RequireAndVerifyClientCert))
+		Expect(auth.clientAuth()).To(Equal(tls.VerifyClientCertIfGiven))
+	})
+})
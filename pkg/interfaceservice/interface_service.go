@@ -30,6 +30,21 @@ type Configuration struct {
 	CertsDir          string        `json:"CertsDirectory"`
 }
 
+// Validate checks the configuration and returns an error listing all
+// problems found
+func (c *Configuration) Validate() error {
+	var v config.Validator
+
+	v.ListenEndpoint("Endpoint", c.Endpoint)
+	v.Check(c.HeartbeatInterval.Duration >= 0,
+		"HeartbeatInterval: must not be negative")
+	v.File("CertsDirectory", filepath.Join(c.CertsDir, auth.CertName))
+	v.File("CertsDirectory", filepath.Join(c.CertsDir, auth.KeyName))
+	v.File("CertsDirectory", filepath.Join(c.CertsDir, auth.CAPoolName))
+
+	return v.Err()
+}
+
 var (
 	log = logger.DefaultLogger.WithField("interface-service", nil)
 	// Config instantiate a configuration
@@ -105,12 +120,17 @@ func runServer(ctx context.Context) error {
 func Run(ctx context.Context, cfgPath string) error {
 	log.Infof("Starting with config: '%s'", cfgPath)
 
-	err := config.LoadJSONConfig(cfgPath, &Config)
+	err := config.LoadConfig(cfgPath, "INTERFACESERVICE", &Config)
 	if err != nil {
 		log.Errf("Failed to load config: %+v", err)
 		return err
 	}
 
+	if err = Config.Validate(); err != nil {
+		log.Errf("Config validation failed: %v", err)
+		return err
+	}
+
 	if _, err := os.Stat("./dpdk-devbind.py"); err != nil {
 		DpdkEnabled = false
 	} else {
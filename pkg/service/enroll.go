@@ -0,0 +1,187 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+
+	"github.com/open-ness/edgenode/pkg/auth"
+	"github.com/open-ness/edgenode/pkg/config"
+	"github.com/pkg/errors"
+)
+
+// Backends issuing credentials, see EnrollConfig
+const (
+	enrollBackendController = "controller"
+	enrollBackendVault      = "vault"
+	enrollBackendEST        = "est"
+)
+
+// VaultConfig is struct that stores configuration of the Vault PKI backend
+// read from json file. The token is better set with the
+// APPLIANCE_ENROLLMENT_VAULT_TOKEN environment variable than in the file.
+type VaultConfig struct {
+	Mount      string `json:"Mount"`
+	Role       string `json:"Role"`
+	Token      string `json:"Token"`
+	CommonName string `json:"CommonName"`
+	CAPath     string `json:"CACertPath"`
+}
+
+// ESTConfig is struct that stores configuration of the EST backend read
+// from json file. The password is better set with the
+// APPLIANCE_ENROLLMENT_EST_PASSWORD environment variable than in the file.
+type ESTConfig struct {
+	Label    string `json:"Label"`
+	Username string `json:"Username"`
+	Password string `json:"Password"`
+	CAPath   string `json:"CACertPath"`
+	CertPath string `json:"CertPath"`
+	KeyPath  string `json:"KeyPath"`
+}
+
+// ControllerPinningConfig is struct that stores pinning of the controller
+// identity read from json file, see auth.ControllerPinning. SPKIHashes are
+// base64 encoded SHA-256 hashes of public keys of the controller
+// certificate chain.
+type ControllerPinningConfig struct {
+	CAPath     string   `json:"CACertPath"`
+	SPKIHashes []string `json:"SPKIHashes"`
+}
+
+// validate records problems of the backend configuration
+func (c *EnrollConfig) validate(v *config.Validator) {
+	switch c.Backend {
+	case "", enrollBackendController:
+	case enrollBackendVault:
+		v.Required("Enrollment.Vault.Role", c.Vault.Role)
+		v.Required("Enrollment.Vault.Token", c.Vault.Token)
+		if c.Vault.CAPath != "" {
+			v.File("Enrollment.Vault.CACertPath", c.Vault.CAPath)
+		}
+	case enrollBackendEST:
+		if c.EST.CAPath != "" {
+			v.File("Enrollment.EST.CACertPath", c.EST.CAPath)
+		}
+		v.Check((c.EST.CertPath == "") == (c.EST.KeyPath == ""),
+			"Enrollment.EST: CertPath and KeyPath must be set together")
+		if c.EST.CertPath != "" {
+			v.File("Enrollment.EST.CertPath", c.EST.CertPath)
+		}
+		if c.EST.KeyPath != "" {
+			v.File("Enrollment.EST.KeyPath", c.EST.KeyPath)
+		}
+	default:
+		v.Check(false, "Enrollment.Backend: unknown backend %q", c.Backend)
+	}
+
+	v.Check(c.RenewLeadTime.Duration >= 0,
+		"Enrollment.RenewalLeadTime: must not be negative")
+	v.Check(c.RenewRetryInterval.Duration >= 0,
+		"Enrollment.RenewalRetryInterval: must not be negative")
+
+	if c.Pinning.CAPath != "" {
+		v.File("Enrollment.ControllerPinning.CACertPath", c.Pinning.CAPath)
+	}
+	for _, pin := range c.Pinning.SPKIHashes {
+		hash, err := base64.StdEncoding.DecodeString(pin)
+		v.Check(err == nil && len(hash) == sha256.Size,
+			"Enrollment.ControllerPinning.SPKIHashes: invalid hash %q", pin)
+	}
+}
+
+// ControllerPinning returns pinning of the controller identity or nil if
+// none is configured. Apart from enrollment with the controller CA, it
+// applies to other connections to the controller, e.g. with
+// TLSConfig(auth.ControllerServerName).
+func (c *EnrollConfig) ControllerPinning() (*auth.ControllerPinning, error) {
+	if c.Pinning.CAPath == "" && len(c.Pinning.SPKIHashes) == 0 {
+		return nil, nil
+	}
+
+	pinning := &auth.ControllerPinning{SPKIHashes: c.Pinning.SPKIHashes}
+	if c.Pinning.CAPath != "" {
+		certs, err := auth.LoadCerts(c.Pinning.CAPath)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to load controller CA certs")
+		}
+		pinning.CACerts = certs
+	}
+	return pinning, nil
+}
+
+// CredentialsClient returns the client of the backend issuing credentials,
+// the controller CA by default
+func (c *EnrollConfig) CredentialsClient() (auth.CredentialsClient, error) {
+	switch c.Backend {
+	case "", enrollBackendController:
+		pinning, err := c.ControllerPinning()
+		if err != nil {
+			return nil, err
+		}
+		return auth.EnrollClient{Pinning: pinning}, nil
+	case enrollBackendVault:
+		tlsCfg, err := clientTLSConfig(c.Vault.CAPath, "", "")
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to create Vault TLS config")
+		}
+		return auth.VaultClient{
+			Mount:      c.Vault.Mount,
+			Role:       c.Vault.Role,
+			Token:      c.Vault.Token,
+			CommonName: c.Vault.CommonName,
+			TLSConfig:  tlsCfg,
+		}, nil
+	case enrollBackendEST:
+		tlsCfg, err := clientTLSConfig(c.EST.CAPath, c.EST.CertPath,
+			c.EST.KeyPath)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to create EST TLS config")
+		}
+		return auth.ESTClient{
+			Label:     c.EST.Label,
+			Username:  c.EST.Username,
+			Password:  c.EST.Password,
+			TLSConfig: tlsCfg,
+		}, nil
+	}
+	return nil, errors.Errorf("Unknown enrollment backend: %s", c.Backend)
+}
+
+// enroll loads credentials of the node from CertsDir or requests them from
+// the configured backend and renews them until ctx is done if
+// RenewLeadTime is set. Nothing is done if Endpoint is not set.
+func (c *EnrollConfig) enroll(ctx context.Context) error {
+	if c.Endpoint == "" {
+		return nil
+	}
+
+	client, err := c.CredentialsClient()
+	if err != nil {
+		return err
+	}
+	if err = auth.Enroll(c.CertsDir, c.Endpoint, c.ConnTimeout.Duration,
+		client); err != nil {
+		return errors.Wrapf(err, "Failed to enroll with %s", c.Endpoint)
+	}
+	Log.Infof("Enrolled credentials in %s", c.CertsDir)
+
+	if c.RenewLeadTime.Duration > 0 {
+		go auth.Renew(ctx, auth.RenewConfig{
+			CertsDir:      c.CertsDir,
+			Endpoint:      c.Endpoint,
+			Timeout:       c.ConnTimeout.Duration,
+			LeadTime:      c.RenewLeadTime.Duration,
+			RetryInterval: c.RenewRetryInterval.Duration,
+			Client:        client,
+			Alert: func(err error) {
+				Log.Alertf("Credentials in %s are not renewed: %v",
+					c.CertsDir, err)
+			},
+		})
+	}
+	return nil
+}
@@ -0,0 +1,249 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/open-ness/edgenode/pkg/auth"
+	"github.com/open-ness/edgenode/pkg/util"
+)
+
+var _ = Describe("EnrollConfig", func() {
+	var (
+		tmpDir   string
+		certPath string
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "enroll")
+		Expect(err).ToNot(HaveOccurred())
+		_, certPath = genServerCert(tmpDir)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tmpDir)
+	})
+
+	It("Will use the controller CA by default", func() {
+		client, err := (&EnrollConfig{}).CredentialsClient()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(client).To(BeAssignableToTypeOf(auth.EnrollClient{}))
+		Expect(client.(auth.EnrollClient).Pinning).To(BeNil())
+	})
+
+	It("Will pin the controller identity", func() {
+		Expect(os.Chmod(certPath, 0644)).To(Succeed())
+		cert, err := auth.LoadCert(certPath)
+		Expect(err).ToNot(HaveOccurred())
+		cfg := EnrollConfig{Pinning: ControllerPinningConfig{
+			CAPath:     certPath,
+			SPKIHashes: []string{auth.SPKIHash(cert)},
+		}}
+		Expect((&MainConfig{Enroll: cfg}).Validate()).To(Succeed())
+
+		client, err := cfg.CredentialsClient()
+		Expect(err).ToNot(HaveOccurred())
+		pinning := client.(auth.EnrollClient).Pinning
+		Expect(pinning).ToNot(BeNil())
+		Expect(pinning.CACerts).To(HaveLen(1))
+		Expect(pinning.SPKIHashes).To(Equal(cfg.Pinning.SPKIHashes))
+
+		cfg.Pinning.SPKIHashes = []string{"invalid"}
+		err = (&MainConfig{Enroll: cfg}).Validate()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring(
+			`Enrollment.ControllerPinning.SPKIHashes: invalid hash "invalid"`))
+	})
+
+	It("Will reject controller not matching the pins", func() {
+		// The controller certificate replaces the one at certPath
+		cert, _ := genServerCert(tmpDir)
+		Expect(os.Chmod(certPath, 0644)).To(Succeed())
+		otherDir, err := ioutil.TempDir(tmpDir, "other")
+		Expect(err).ToNot(HaveOccurred())
+		other, _ := genServerCert(otherDir)
+		otherCert, err := x509.ParseCertificate(other.Certificate[0])
+		Expect(err).ToNot(HaveOccurred())
+
+		lis, err := tls.Listen("tcp", "127.0.0.1:0",
+			&tls.Config{Certificates: []tls.Certificate{cert}})
+		Expect(err).ToNot(HaveOccurred())
+		defer lis.Close()
+		handshakes := make(chan error, 100)
+		go func() {
+			for {
+				conn, err := lis.Accept()
+				if err != nil {
+					return
+				}
+				handshakes <- conn.(*tls.Conn).Handshake()
+				conn.Close()
+			}
+		}()
+
+		enroll := func(pin *x509.Certificate) error {
+			cfg := EnrollConfig{
+				Endpoint:    lis.Addr().String(),
+				ConnTimeout: util.Duration{Duration: 500 * time.Millisecond},
+				CertsDir:    filepath.Join(tmpDir, "enrolled"),
+				Pinning: ControllerPinningConfig{
+					CAPath:     certPath,
+					SPKIHashes: []string{auth.SPKIHash(pin)},
+				},
+			}
+			Expect((&MainConfig{Enroll: cfg}).Validate()).To(Succeed())
+			return cfg.enroll(context.Background())
+		}
+
+		Expect(enroll(otherCert)).ToNot(Succeed())
+		Expect(handshakes).To(Receive(MatchError(
+			ContainSubstring("bad certificate"))))
+
+		// The same controller is accepted with its own pin
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		Expect(err).ToNot(HaveOccurred())
+		Expect(enroll(leaf)).ToNot(Succeed())
+		Eventually(handshakes).Should(Receive(BeNil()))
+	})
+
+	It("Will use the selected backend", func() {
+		cfg := EnrollConfig{
+			Backend: "vault",
+			Vault: VaultConfig{Role: "node", Token: "s.token",
+				CAPath: certPath},
+		}
+		client, err := cfg.CredentialsClient()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(client).To(BeAssignableToTypeOf(auth.VaultClient{}))
+		vault := client.(auth.VaultClient)
+		Expect(vault.Role).To(Equal("node"))
+		Expect(vault.TLSConfig.RootCAs).ToNot(BeNil())
+
+		cfg = EnrollConfig{Backend: "est", EST: ESTConfig{Label: "nodes"}}
+		client, err = cfg.CredentialsClient()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(client).To(BeAssignableToTypeOf(auth.ESTClient{}))
+		Expect(client.(auth.ESTClient).Label).To(Equal("nodes"))
+	})
+
+	It("Will enroll with the selected backend", func() {
+		requests := make(chan string, 10)
+		server := httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				requests <- r.URL.Path
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}))
+		defer server.Close()
+
+		for backend, path := range map[string]string{
+			"vault": "/v1/pki/sign/node",
+			"est":   "/.well-known/est/cacerts",
+		} {
+			cfg := EnrollConfig{
+				Endpoint:    server.URL,
+				ConnTimeout: util.Duration{Duration: time.Second},
+				CertsDir:    filepath.Join(tmpDir, backend),
+				Backend:     backend,
+				Vault:       VaultConfig{Role: "node", Token: "s.token"},
+			}
+			Expect(cfg.enroll(context.Background())).ToNot(Succeed(), backend)
+			Expect(requests).To(Receive(Equal(path)), backend)
+			Expect(requests).ToNot(Receive(), backend)
+		}
+	})
+
+	It("Will renew enrolled credentials", func() {
+		ca, _ := genServerCert(tmpDir)
+		caCert, err := x509.ParseCertificate(ca.Certificate[0])
+		Expect(err).ToNot(HaveOccurred())
+		caPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE",
+			Bytes: caCert.Raw}))
+
+		signed := make(chan struct{}, 10)
+		server := httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				defer GinkgoRecover()
+				var req struct {
+					CSR string `json:"csr"`
+				}
+				Expect(json.NewDecoder(r.Body).Decode(&req)).To(Succeed())
+				block, _ := pem.Decode([]byte(req.CSR))
+				Expect(block).ToNot(BeNil())
+				csr, err := x509.ParseCertificateRequest(block.Bytes)
+				Expect(err).ToNot(HaveOccurred())
+
+				der, err := x509.CreateCertificate(rand.Reader,
+					&x509.Certificate{
+						SerialNumber: big.NewInt(2),
+						NotBefore:    time.Now(),
+						NotAfter:     time.Now().Add(time.Hour),
+					}, caCert, csr.PublicKey, ca.PrivateKey)
+				Expect(err).ToNot(HaveOccurred())
+				var resp struct {
+					Data struct {
+						Certificate string `json:"certificate"`
+						IssuingCA   string `json:"issuing_ca"`
+					} `json:"data"`
+				}
+				resp.Data.Certificate = string(pem.EncodeToMemory(
+					&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+				resp.Data.IssuingCA = caPEM
+				Expect(json.NewEncoder(w).Encode(resp)).To(Succeed())
+				signed <- struct{}{}
+			}))
+		defer server.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		cfg := EnrollConfig{
+			Endpoint:    server.URL,
+			ConnTimeout: util.Duration{Duration: time.Second},
+			CertsDir:    filepath.Join(tmpDir, "certs"),
+			Backend:     "vault",
+			Vault:       VaultConfig{Role: "node", Token: "s.token"},
+			// Renewal is due a couple of seconds after enrollment
+			RenewLeadTime: util.Duration{Duration: time.Hour - 2*time.Second},
+		}
+		Expect(cfg.enroll(ctx)).To(Succeed())
+		Expect(signed).To(Receive())
+		Eventually(signed, 5*time.Second).Should(Receive())
+	})
+
+	It("Will skip enrollment without endpoint", func() {
+		Expect((&EnrollConfig{CertsDir: filepath.Join(tmpDir, "certs")}).
+			enroll(context.Background())).To(Succeed())
+		Expect(filepath.Join(tmpDir, "certs")).ToNot(BeADirectory())
+	})
+
+	It("Will reject incomplete backend configuration", func() {
+		cfg := MainConfig{Enroll: EnrollConfig{Backend: "vault"}}
+		err := cfg.Validate()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Enrollment.Vault.Role"))
+		Expect(err.Error()).To(ContainSubstring("Enrollment.Vault.Token"))
+
+		cfg = MainConfig{Enroll: EnrollConfig{Backend: "acme"}}
+		err = cfg.Validate()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring(
+			`Enrollment.Backend: unknown backend "acme"`))
+	})
+})
@@ -0,0 +1,147 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// LogFormatText is the default, free-form log output
+	LogFormatText = "text"
+	// LogFormatJSON emits one JSON object per log line
+	LogFormatJSON = "json"
+)
+
+// parseLogLine splits the header the logger prepends to lines of the local
+// output, "<PRI>Stamp svc[pid]: ", from the message. Lines without the
+// header are returned whole and ok is false.
+func parseLogLine(line string) (pri int, msg string, ok bool) {
+	end := strings.IndexByte(line, '>')
+	if !strings.HasPrefix(line, "<") || end < 0 {
+		return 0, line, false
+	}
+	pri, err := strconv.Atoi(line[1:end])
+	if err != nil || pri < 0 {
+		return 0, line, false
+	}
+	sep := strings.Index(line[end:], "]: ")
+	if sep < 0 {
+		return 0, line, false
+	}
+	return pri, line[end+sep+len("]: "):], true
+}
+
+// Tags of log lines lifted to their own fields of jsonLogEntry
+const (
+	logTagAppID         = "app_id"
+	logTagCorrelationID = "correlation_id"
+)
+
+// logLevelNames are names of syslog severities as accepted by LogLevel
+var logLevelNames = []string{"emerg", "alert", "crit", "err", "warning",
+	"notice", "info", "debug"}
+
+// jsonLogEntry is a single log line as emitted in JSON log format. Level
+// is the severity of lines written by the logger, AppID and CorrelationID
+// are taken from their tags.
+type jsonLogEntry struct {
+	Timestamp     string `json:"timestamp"`
+	Module        string `json:"module"`
+	Level         string `json:"level,omitempty"`
+	AppID         string `json:"app_id,omitempty"`
+	CorrelationID string `json:"correlation_id,omitempty"`
+	Message       string `json:"message"`
+}
+
+// jsonLogWriter wraps every line written to it into a jsonLogEntry
+// and writes the encoded entry to the underlying writer
+type jsonLogWriter struct {
+	mu     sync.Mutex
+	out    io.Writer
+	module string
+	now    func() time.Time
+}
+
+func newJSONLogWriter(out io.Writer, module string) *jsonLogWriter {
+	return &jsonLogWriter{out: out, module: module, now: time.Now}
+}
+
+// entry creates the entry of the line, the local header of the line is
+// replaced by the level and tags known to the entry are moved from the
+// message to their fields
+func (w *jsonLogWriter) entry(line, ts string) jsonLogEntry {
+	e := jsonLogEntry{Timestamp: ts, Module: w.module}
+	pri, msg, ok := parseLogLine(line)
+	if ok {
+		e.Level = logLevelNames[pri&0x07]
+	}
+
+	var kept []string
+	for strings.HasPrefix(msg, "[") {
+		end := strings.Index(msg, "] ")
+		if end < 0 {
+			break
+		}
+		tag := msg[1:end]
+		msg = msg[end+len("] "):]
+
+		kv := strings.SplitN(tag, "=", 2)
+		switch {
+		case len(kv) == 2 && kv[0] == logTagAppID:
+			e.AppID = kv[1]
+		case len(kv) == 2 && kv[0] == logTagCorrelationID:
+			e.CorrelationID = kv[1]
+		default:
+			kept = append(kept, "["+tag+"]")
+		}
+	}
+	e.Message = strings.Join(append(kept, msg), " ")
+	return e
+}
+
+func (w *jsonLogWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	ts := w.now().UTC().Format(time.RFC3339Nano)
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, line := range strings.Split(string(p), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line == "" {
+			continue
+		}
+		if err := enc.Encode(w.entry(line, ts)); err != nil {
+			return 0, errors.Wrap(err, "Failed to encode log entry")
+		}
+	}
+
+	if _, err := w.out.Write(buf.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// logOutput returns the local log output for the requested format
+func logOutput(format string) (io.Writer, error) {
+	switch strings.ToLower(format) {
+	case "", LogFormatText:
+		return os.Stderr, nil
+	case LogFormatJSON:
+		return newJSONLogWriter(os.Stderr, filepath.Base(os.Args[0])), nil
+	default:
+		return nil, errors.Errorf("unknown log format: %s", format)
+	}
+}
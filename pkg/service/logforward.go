@@ -0,0 +1,220 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package service
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// syslog PRI for facility daemon and severity info, used for lines
+	// not written by the logger
+	syslogPriDaemonInfo = 3*8 + 6
+	logForwardTimeout   = 5 * time.Second
+	logForwardQueueSize = 1024
+)
+
+// Bounds of the backoff between attempts to send to the server
+var (
+	logForwardMinBackoff = time.Second
+	logForwardMaxBackoff = time.Minute
+)
+
+// LogForwardConfig is struct that stores configuration of the remote
+// syslog over TLS (RFC 5425) sink read from json file
+type LogForwardConfig struct {
+	Address  string `json:"Address"`
+	CAPath   string `json:"CACertPath"`
+	CertPath string `json:"CertPath"`
+	KeyPath  string `json:"KeyPath"`
+}
+
+// syslogTLSWriter forwards every line written to it to a remote syslog
+// server over TLS. Lines are queued and sent in the background, so a slow
+// or unreachable server never blocks local logging: lines that don't fit
+// in the queue are dropped and the connection is retried with exponential
+// backoff.
+type syslogTLSWriter struct {
+	addr       string
+	tlsCfg     *tls.Config
+	hostname   string
+	appName    string
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	queue      chan []byte
+	done       chan struct{}
+	closeOnce  sync.Once
+	// conn is used only by the sender
+	conn net.Conn
+}
+
+// clientTLSConfig creates TLS config of connections to a server verified
+// with CA cert from caPath and authenticating with client cert/key pair.
+// All paths are optional, system roots are trusted without the CA cert.
+func clientTLSConfig(caPath, certPath, keyPath string) (*tls.Config, error) {
+	tlsCfg := &tls.Config{}
+	if caPath != "" {
+		ca, err := ioutil.ReadFile(filepath.Clean(caPath))
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to load CA Cert")
+		}
+		certPool := x509.NewCertPool()
+		if ok := certPool.AppendCertsFromPEM(ca); !ok {
+			return nil, errors.New("Failed to append cert to pool")
+		}
+		tlsCfg.RootCAs = certPool
+	}
+	if certPath != "" || keyPath != "" {
+		cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+		if err != nil {
+			return nil, errors.Wrap(err,
+				"Failed to load Client Cert/Key pair")
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+	return tlsCfg, nil
+}
+
+// newSyslogTLSWriter creates the writer and starts its sender, which runs
+// until the writer is closed
+func newSyslogTLSWriter(cfg LogForwardConfig) (*syslogTLSWriter, error) {
+	host, _, err := net.SplitHostPort(cfg.Address)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Invalid address: %s", cfg.Address)
+	}
+
+	tlsCfg, err := clientTLSConfig(cfg.CAPath, cfg.CertPath, cfg.KeyPath)
+	if err != nil {
+		return nil, err
+	}
+	tlsCfg.ServerName = host
+
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+
+	w := &syslogTLSWriter{
+		addr:       cfg.Address,
+		tlsCfg:     tlsCfg,
+		hostname:   hostname,
+		appName:    filepath.Base(os.Args[0]),
+		minBackoff: logForwardMinBackoff,
+		maxBackoff: logForwardMaxBackoff,
+		queue:      make(chan []byte, logForwardQueueSize),
+		done:       make(chan struct{}),
+	}
+	go w.run()
+	return w, nil
+}
+
+// close stops the sender and closes the connection, lines still queued
+// are dropped
+func (w *syslogTLSWriter) close() {
+	w.closeOnce.Do(func() { close(w.done) })
+}
+
+// run sends queued lines until the writer is closed. A line that fails to
+// be sent is retried with exponential backoff, meanwhile new lines wait in
+// the queue or are dropped when it's full.
+func (w *syslogTLSWriter) run() {
+	defer w.disconnect()
+
+	backoff := w.minBackoff
+	for {
+		var frames []byte
+		select {
+		case <-w.done:
+			return
+		case frames = <-w.queue:
+		}
+
+		for w.send(frames) != nil {
+			timer := time.NewTimer(backoff)
+			select {
+			case <-w.done:
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+			if backoff *= 2; backoff > w.maxBackoff {
+				backoff = w.maxBackoff
+			}
+		}
+		backoff = w.minBackoff
+	}
+}
+
+// send writes frames to the server, connecting first if needed
+func (w *syslogTLSWriter) send(frames []byte) error {
+	if w.conn == nil {
+		dialer := &net.Dialer{Timeout: logForwardTimeout}
+		conn, err := tls.DialWithDialer(dialer, "tcp", w.addr, w.tlsCfg)
+		if err != nil {
+			return err
+		}
+		w.conn = conn
+	}
+
+	_ = w.conn.SetWriteDeadline(time.Now().Add(logForwardTimeout))
+	if _, err := w.conn.Write(frames); err != nil {
+		w.disconnect()
+		return err
+	}
+	return nil
+}
+
+func (w *syslogTLSWriter) disconnect() {
+	if w.conn != nil {
+		_ = w.conn.Close()
+		w.conn = nil
+	}
+}
+
+// frame builds RFC 5424 message with RFC 5425 octet-counting framing. The
+// PRI of the line is kept and its local header is replaced by the RFC 5424
+// one.
+func (w *syslogTLSWriter) frame(line string) string {
+	pri, msg, ok := parseLogLine(line)
+	if !ok {
+		pri = syslogPriDaemonInfo
+	}
+	m := fmt.Sprintf("<%d>1 %s %s %s %d - - %s", pri,
+		time.Now().UTC().Format(time.RFC3339Nano), w.hostname,
+		w.appName, os.Getpid(), msg)
+	return fmt.Sprintf("%d %s", len(m), m)
+}
+
+// Write queues lines of p for the sender, it never blocks and never fails
+func (w *syslogTLSWriter) Write(p []byte) (int, error) {
+	var frames strings.Builder
+	for _, line := range strings.Split(string(p), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line == "" {
+			continue
+		}
+		frames.WriteString(w.frame(line))
+	}
+	if frames.Len() == 0 {
+		return len(p), nil
+	}
+
+	select {
+	case w.queue <- []byte(frames.String()):
+	default:
+		// The queue is full, the lines are dropped
+	}
+	return len(p), nil
+}
@@ -0,0 +1,231 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package service
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/open-ness/edgenode/pkg/auth"
+)
+
+// genServerCert creates self-signed cert for 127.0.0.1 and the enrollment
+// server name and stores it with its key in dir
+func genServerCert(dir string) (tls.Certificate, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).ToNot(HaveOccurred())
+
+	template := x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "syslog"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		DNSNames:              []string{auth.EnrollServerName},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		KeyUsage: x509.KeyUsageCertSign |
+			x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template,
+		&key.PublicKey, key)
+	Expect(err).ToNot(HaveOccurred())
+
+	certPath := filepath.Join(dir, "cert.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	Expect(ioutil.WriteFile(certPath, certPEM, 0600)).To(Succeed())
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	Expect(err).ToNot(HaveOccurred())
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY",
+		Bytes: keyDER})
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	Expect(err).ToNot(HaveOccurred())
+	return cert, certPath
+}
+
+// readFrame reads single octet-counted syslog frame
+func readFrame(r *bufio.Reader) string {
+	lenStr, err := r.ReadString(' ')
+	Expect(err).ToNot(HaveOccurred())
+	n, err := strconv.Atoi(strings.TrimSpace(lenStr))
+	Expect(err).ToNot(HaveOccurred())
+	buf := make([]byte, n)
+	_, err = io.ReadFull(r, buf)
+	Expect(err).ToNot(HaveOccurred())
+	return string(buf)
+}
+
+var _ = Describe("syslogTLSWriter", func() {
+	var (
+		tmpDir   string
+		certPath string
+		lis      net.Listener
+	)
+
+	BeforeEach(func() {
+		var (
+			err  error
+			cert tls.Certificate
+		)
+		tmpDir, err = ioutil.TempDir("", "logforward")
+		Expect(err).ToNot(HaveOccurred())
+
+		cert, certPath = genServerCert(tmpDir)
+		lis, err = tls.Listen("tcp", "127.0.0.1:0",
+			&tls.Config{Certificates: []tls.Certificate{cert}})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		lis.Close()
+		os.RemoveAll(tmpDir)
+	})
+
+	It("Will forward every line as a framed syslog message", func() {
+		w, err := newSyslogTLSWriter(LogForwardConfig{
+			Address: lis.Addr().String(),
+			CAPath:  certPath,
+		})
+		Expect(err).ToNot(HaveOccurred())
+		defer w.close()
+
+		received := make(chan string, 2)
+		go func() {
+			defer GinkgoRecover()
+			conn, err := lis.Accept()
+			Expect(err).ToNot(HaveOccurred())
+			defer conn.Close()
+			r := bufio.NewReader(conn)
+			received <- readFrame(r)
+			received <- readFrame(r)
+		}()
+
+		// The first line is written by the logger, with its local header
+		_, err = w.Write([]byte(
+			"<11>Jan  2 15:04:05 appliance[42]: first line\nsecond line\n"))
+		Expect(err).ToNot(HaveOccurred())
+
+		for _, expected := range []struct{ pri, msg string }{
+			{"<11>", "first line"},
+			{"<30>", "second line"},
+		} {
+			var frame string
+			Eventually(received, 5*time.Second).Should(Receive(&frame))
+			Expect(frame).To(HavePrefix(expected.pri + "1 "))
+			Expect(frame).To(HaveSuffix(" - - " + expected.msg))
+			Expect(frame).ToNot(ContainSubstring("appliance[42]"))
+		}
+	})
+
+	It("Will not block when server is not reachable", func() {
+		// The server accepts connections but never completes the TLS
+		// handshake
+		stalled, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+		defer stalled.Close()
+
+		w, err := newSyslogTLSWriter(LogForwardConfig{
+			Address: stalled.Addr().String(),
+			CAPath:  certPath,
+		})
+		Expect(err).ToNot(HaveOccurred())
+		defer w.close()
+
+		start := time.Now()
+		for i := 0; i < 2*logForwardQueueSize; i++ {
+			n, err := w.Write([]byte("lost line\n"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(n).To(Equal(len("lost line\n")))
+		}
+		Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+	})
+
+	It("Will reconnect when connection is lost", func() {
+		defer func(backoff time.Duration) {
+			logForwardMinBackoff = backoff
+		}(logForwardMinBackoff)
+		logForwardMinBackoff = 10 * time.Millisecond
+
+		w, err := newSyslogTLSWriter(LogForwardConfig{
+			Address: lis.Addr().String(),
+			CAPath:  certPath,
+		})
+		Expect(err).ToNot(HaveOccurred())
+		defer w.close()
+
+		received := make(chan string, 1)
+		go func() {
+			defer GinkgoRecover()
+			conn, err := lis.Accept()
+			Expect(err).ToNot(HaveOccurred())
+			r := bufio.NewReader(conn)
+			received <- readFrame(r)
+			conn.Close()
+
+			conn, err = lis.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			r = bufio.NewReader(conn)
+			for {
+				lenStr, err := r.ReadString(' ')
+				if err != nil {
+					return
+				}
+				n, _ := strconv.Atoi(strings.TrimSpace(lenStr))
+				buf := make([]byte, n)
+				if _, err = io.ReadFull(r, buf); err != nil {
+					return
+				}
+				select {
+				case received <- string(buf):
+				default:
+				}
+			}
+		}()
+
+		_, err = w.Write([]byte("first line\n"))
+		Expect(err).ToNot(HaveOccurred())
+		Eventually(received, 5*time.Second).Should(Receive(
+			HaveSuffix(" - - first line")))
+
+		Eventually(func() <-chan string {
+			_, err := w.Write([]byte("next line\n"))
+			Expect(err).ToNot(HaveOccurred())
+			return received
+		}, 5*time.Second, 50*time.Millisecond).Should(Receive(
+			HaveSuffix(" - - next line")))
+	})
+
+	It("Will fail for not existing CA cert", func() {
+		_, err := newSyslogTLSWriter(LogForwardConfig{
+			Address: lis.Addr().String(),
+			CAPath:  filepath.Join(tmpDir, "notExistFile.pem"),
+		})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Failed to load CA Cert"))
+	})
+})
@@ -6,6 +6,7 @@ package service
 import (
 	"context"
 	"flag"
+	"io"
 	"os"
 	"os/signal"
 	"reflect"
@@ -24,11 +25,21 @@ import (
 // StartFunction is func typedef for starting service
 type StartFunction func(context.Context, string) error
 
-// EnrollConfig is struct that stores configuration of enrollment read from json file
+// EnrollConfig is struct that stores configuration of enrollment read from json file.
+// Backend selects the CA issuing credentials, see CredentialsClient.
+// Endpoint is the address of the controller or the URL of Vault or of
+// the EST server. Credentials are renewed RenewLeadTime ahead of expiry if
+// it's set, see auth.RenewConfig.
 type EnrollConfig struct {
-	Endpoint    string        `json:"Endpoint"`
-	ConnTimeout util.Duration `json:"ConnectionTimeout"`
-	CertsDir    string        `json:"CertsDirectory"`
+	Endpoint           string                  `json:"Endpoint"`
+	ConnTimeout        util.Duration           `json:"ConnectionTimeout"`
+	CertsDir           string                  `json:"CertsDirectory"`
+	Backend            string                  `json:"Backend"`
+	Vault              VaultConfig             `json:"Vault"`
+	EST                ESTConfig               `json:"EST"`
+	Pinning            ControllerPinningConfig `json:"ControllerPinning"`
+	RenewLeadTime      util.Duration           `json:"RenewalLeadTime"`
+	RenewRetryInterval util.Duration           `json:"RenewalRetryInterval"`
 }
 
 // MainConfig is struct that stores configuration read from json file
@@ -36,10 +47,38 @@ type MainConfig struct {
 	UseSyslog  bool              `json:"UseSyslog"`
 	SyslogAddr string            `json:"SyslogAddr"`
 	LogLevel   string            `json:"LogLevel"`
+	LogFormat  string            `json:"LogFormat"`
+	LogForward LogForwardConfig  `json:"LogForwarding"`
 	Services   map[string]string `json:"Services"`
 	Enroll     EnrollConfig      `json:"Enrollment"`
 }
 
+// Validate checks the configuration and returns an error listing all
+// problems found
+func (c *MainConfig) Validate() error {
+	var v config.Validator
+
+	if c.LogForward.Address != "" {
+		v.Endpoint("LogForwarding.Address", c.LogForward.Address)
+		if c.LogForward.CAPath != "" {
+			v.File("LogForwarding.CACertPath", c.LogForward.CAPath)
+		}
+		v.Check((c.LogForward.CertPath == "") == (c.LogForward.KeyPath == ""),
+			"LogForwarding: CertPath and KeyPath must be set together")
+		if c.LogForward.CertPath != "" {
+			v.File("LogForwarding.CertPath", c.LogForward.CertPath)
+		}
+		if c.LogForward.KeyPath != "" {
+			v.File("LogForwarding.KeyPath", c.LogForward.KeyPath)
+		}
+	}
+	v.Check(c.Enroll.ConnTimeout.Duration >= 0,
+		"Enrollment.ConnectionTimeout: must not be negative")
+	c.Enroll.validate(&v)
+
+	return v.Err()
+}
+
 // Cfg is variable that stores config
 var Cfg MainConfig
 
@@ -54,26 +93,121 @@ func init() {
 
 // InitConfig load configuration from cfg file
 func InitConfig(cfgPath string) error {
-	err := config.LoadJSONConfig(cfgPath, &Cfg)
+	err := config.LoadConfig(cfgPath, "APPLIANCE", &Cfg)
 	if err != nil {
 		return errors.Wrapf(err,
 			"Failed to load config: %s", cfgPath)
 	}
 
-	if Cfg.UseSyslog {
-		err = logger.ConnectSyslog(Cfg.SyslogAddr)
+	if err = Cfg.Validate(); err != nil {
+		return errors.Wrapf(err,
+			"Failed to validate config: %s", cfgPath)
+	}
+
+	return setupLogging(&Cfg)
+}
+
+// ReloadConfig reloads logging configuration from cfg file. Other
+// settings are used only at startup and are left untouched.
+func ReloadConfig(cfgPath string) error {
+	var cfg MainConfig
+	err := config.LoadConfig(cfgPath, "APPLIANCE", &cfg)
+	if err != nil {
+		return errors.Wrapf(err,
+			"Failed to load config: %s", cfgPath)
+	}
+
+	if err = cfg.Validate(); err != nil {
+		return errors.Wrapf(err,
+			"Failed to validate config: %s", cfgPath)
+	}
+
+	if err = setupLogging(&cfg); err != nil {
+		return err
+	}
+
+	Cfg.UseSyslog = cfg.UseSyslog
+	Cfg.SyslogAddr = cfg.SyslogAddr
+	Cfg.LogLevel = cfg.LogLevel
+	Cfg.LogFormat = cfg.LogFormat
+	Cfg.LogForward = cfg.LogForward
+	return nil
+}
+
+// logForwarder is the remote syslog sink installed by setupLogging, it's
+// closed when replaced by the next configuration
+var logForwarder *syslogTLSWriter
+
+// syslogConnected tells if the logger is connected to syslog at syslogAddr
+var (
+	syslogConnected bool
+	syslogAddr      string
+)
+
+// disconnectSyslog closes the syslog connection of the logger, if any
+func disconnectSyslog() {
+	if !syslogConnected {
+		return
+	}
+	if err := logger.DisconnectSyslog(); err != nil {
+		Log.Errf("Failed to disconnect from syslog: %v", err)
+	}
+	syslogConnected = false
+}
+
+// setupLogging applies logging part of the configuration. Everything is
+// set up before the new output is installed, so on failure the previous
+// configuration stays in effect. The exception is a new syslog address:
+// the logger holds a single syslog connection, so the previous one is
+// closed before dialing the new address.
+func setupLogging(cfg *MainConfig) error {
+	lvl, err := logger.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		return errors.Wrapf(err,
+			"Failed to parse log level: %s", cfg.LogLevel)
+	}
+
+	// Log format applies to local output only, syslog keeps its own format
+	out, err := logOutput(cfg.LogFormat)
+	if err != nil {
+		return errors.Wrapf(err,
+			"Failed to set log format: %s", cfg.LogFormat)
+	}
+
+	var fwd *syslogTLSWriter
+	if cfg.LogForward.Address != "" {
+		fwd, err = newSyslogTLSWriter(cfg.LogForward)
 		if err != nil {
 			return errors.Wrapf(err,
-				"Failed to connect to syslog: %s", Cfg.SyslogAddr)
+				"Failed to set up log forwarding: %s",
+				cfg.LogForward.Address)
 		}
+		out = io.MultiWriter(out, fwd)
 	}
 
-	lvl, err := logger.ParseLevel(Cfg.LogLevel)
-	if err != nil {
-		return errors.Wrapf(err,
-			"Failed to parse log level: %s", Cfg.LogLevel)
+	if cfg.UseSyslog && (!syslogConnected || syslogAddr != cfg.SyslogAddr) {
+		disconnectSyslog()
+		err = logger.ConnectSyslog(cfg.SyslogAddr)
+		if err != nil {
+			if fwd != nil {
+				fwd.close()
+			}
+			return errors.Wrapf(err,
+				"Failed to connect to syslog: %s", cfg.SyslogAddr)
+		}
+		syslogConnected, syslogAddr = true, cfg.SyslogAddr
 	}
+
+	logger.SetOutput(out)
 	logger.SetLevel(lvl)
+	if !cfg.UseSyslog {
+		disconnectSyslog()
+	}
+
+	if logForwarder != nil {
+		logForwarder.close()
+	}
+	logForwarder = fwd
 	return nil
 }
 
@@ -107,6 +241,7 @@ func WaitForServices(wg *sync.WaitGroup,
 // RunServices starts the services provided in slice
 func RunServices(services []StartFunction) bool {
 	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 	var wg sync.WaitGroup
 
 	flag.Parse()
@@ -114,6 +249,10 @@ func RunServices(services []StartFunction) bool {
 		Log.Errf("InitConfig failed %v\n", err)
 		os.Exit(1)
 	}
+	if err := Cfg.Enroll.enroll(ctx); err != nil {
+		Log.Errf("Enrollment failed %v", err)
+		os.Exit(1)
+	}
 	// Handle SIGINT and SIGTERM by calling cancel()
 	// which is propagated to services
 	osSignals := make(chan os.Signal, 1)
@@ -124,6 +263,24 @@ func RunServices(services []StartFunction) bool {
 		cancel()
 	}()
 
+	// Handle SIGHUP by reloading logging configuration
+	reloadSignals := make(chan os.Signal, 1)
+	signal.Notify(reloadSignals, syscall.SIGHUP)
+	go func() {
+		defer signal.Stop(reloadSignals)
+		for {
+			select {
+			case <-reloadSignals:
+				Log.Infof("Reloading config: %s", cfgPath)
+				if err := ReloadConfig(cfgPath); err != nil {
+					Log.Errf("ReloadConfig failed %v", err)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
 	results := make(chan error)
 
 	Log.Infof("Starting services")
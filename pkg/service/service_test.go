@@ -4,8 +4,11 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"net"
 	"os"
 	"reflect"
 	"runtime"
@@ -15,6 +18,8 @@ import (
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	logger "github.com/open-ness/common/log"
 )
 
 var _ = func() (_ struct{}) {
@@ -128,6 +133,40 @@ var _ = Describe("init", func() {
 			})
 	})
 
+	Describe("Init config with incorrect log format", func() {
+		It("Will return failure",
+			func() {
+				defer func() { Cfg.LogFormat = "" }()
+				err := InitConfig("testdata/logFormat.json")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Failed to set log format"))
+			})
+	})
+
+	Describe("Init config with incorrect log forwarding address", func() {
+		It("Will return failure",
+			func() {
+				defer func() { Cfg.LogForward = LogForwardConfig{} }()
+				err := InitConfig("testdata/logForward.json")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Failed to validate config"))
+				Expect(err.Error()).To(ContainSubstring("LogForwarding.Address"))
+			})
+	})
+
+	Describe("Init config with invalid log forwarding credentials", func() {
+		It("Will return failure listing all problems",
+			func() {
+				defer func() { Cfg.LogForward = LogForwardConfig{} }()
+				err := InitConfig("testdata/logForwardCreds.json")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Failed to validate config"))
+				Expect(err.Error()).To(ContainSubstring("LogForwarding.CACertPath"))
+				Expect(err.Error()).To(ContainSubstring(
+					"CertPath and KeyPath must be set together"))
+			})
+	})
+
 	Describe("Init config with incorrect syslog address", func() {
 		It("Will return failure",
 			func() {
@@ -137,3 +176,137 @@ var _ = Describe("init", func() {
 			})
 	})
 })
+
+var _ = Describe("reload", func() {
+	BeforeEach(func() {
+		Expect(InitConfig("../../configs/appliance.json")).To(Succeed())
+	})
+
+	Describe("Reload config with correct cfg file", func() {
+		It("Will apply new logging configuration",
+			func() {
+				Expect(ReloadConfig("testdata/reload.json")).To(Succeed())
+				Expect(Cfg.LogLevel).To(Equal("debug"))
+				Expect(Cfg.Services).ToNot(BeEmpty())
+			})
+	})
+
+	Describe("Reload config with incorrect parse level", func() {
+		It("Will return failure and keep previous configuration",
+			func() {
+				err := ReloadConfig("testdata/parseLevel.json")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Failed to parse log level"))
+				Expect(Cfg.LogLevel).To(Equal("info"))
+			})
+	})
+
+	Describe("Reload config with incorrect syslog address", func() {
+		It("Will return failure and keep previous output",
+			func() {
+				out := gbytes.NewBuffer()
+				logger.SetOutput(out)
+				err := ReloadConfig("testdata/useSyslog.json")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Failed to connect to syslog"))
+				Expect(Cfg.UseSyslog).To(BeFalse())
+
+				Log.Errf("Logged after failed reload")
+				Expect(out).To(gbytes.Say("Logged after failed reload"))
+			})
+	})
+
+	Describe("Reload config with syslog", func() {
+		It("Will keep or replace the connection",
+			func() {
+				defer func() {
+					Expect(setupLogging(&Cfg)).To(Succeed())
+				}()
+				listen := func() net.PacketConn {
+					conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+					Expect(err).ToNot(HaveOccurred())
+					return conn
+				}
+				first, second := listen(), listen()
+				defer first.Close()
+				defer second.Close()
+
+				cfg := Cfg
+				cfg.UseSyslog = true
+				cfg.SyslogAddr = first.LocalAddr().String()
+				Expect(setupLogging(&cfg)).To(Succeed())
+				Expect(setupLogging(&cfg)).To(Succeed())
+
+				cfg.SyslogAddr = second.LocalAddr().String()
+				Expect(setupLogging(&cfg)).To(Succeed())
+				Log.Errf("Logged to second syslog")
+				buf := make([]byte, 1024)
+				Expect(second.SetReadDeadline(
+					time.Now().Add(time.Second))).To(Succeed())
+				n, _, err := second.ReadFrom(buf)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(buf[:n])).To(
+					ContainSubstring("Logged to second syslog"))
+
+				cfg.UseSyslog = false
+				Expect(setupLogging(&cfg)).To(Succeed())
+				Expect(syslogConnected).To(BeFalse())
+			})
+	})
+
+	Describe("Reload config with log forwarding", func() {
+		It("Will close previous forwarder",
+			func() {
+				defer func() {
+					Expect(setupLogging(&Cfg)).To(Succeed())
+				}()
+				cfg := Cfg
+				cfg.LogForward = LogForwardConfig{Address: "127.0.0.1:1"}
+				Expect(setupLogging(&cfg)).To(Succeed())
+				prev := logForwarder
+				Expect(prev).ToNot(BeNil())
+
+				Expect(setupLogging(&cfg)).To(Succeed())
+				Expect(logForwarder).ToNot(BeIdenticalTo(prev))
+				Expect(prev.done).To(BeClosed())
+			})
+	})
+})
+
+var _ = Describe("jsonLogWriter", func() {
+	It("Will write every line as a separate JSON entry", func() {
+		var buf bytes.Buffer
+		w := newJSONLogWriter(&buf, "appliance")
+
+		n, err := w.Write([]byte("first line\nsecond line\n"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(n).To(Equal(len("first line\nsecond line\n")))
+
+		dec := json.NewDecoder(&buf)
+		for _, msg := range []string{"first line", "second line"} {
+			var entry jsonLogEntry
+			Expect(dec.Decode(&entry)).To(Succeed())
+			Expect(entry.Module).To(Equal("appliance"))
+			Expect(entry.Message).To(Equal(msg))
+			_, err = time.Parse(time.RFC3339Nano, entry.Timestamp)
+			Expect(err).ToNot(HaveOccurred())
+		}
+		Expect(dec.More()).To(BeFalse())
+	})
+
+	It("Will replace the local header with the level and tags", func() {
+		var buf bytes.Buffer
+		w := newJSONLogWriter(&buf, "appliance")
+		w.now = func() time.Time {
+			return time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+		}
+
+		_, err := w.Write([]byte("<131>Oct  1 12:00:00 appliance[42]: " +
+			"[eaa] [app_id=ns:app] [correlation_id=req-1] Request failed\n"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(buf.String()).To(Equal(`{"timestamp":"2020-10-01T12:00:00Z",` +
+			`"module":"appliance","level":"err","app_id":"ns:app",` +
+			`"correlation_id":"req-1","message":"[eaa] Request failed"}` +
+			"\n"))
+	})
+})